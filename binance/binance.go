@@ -0,0 +1,369 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.Exchange = (*Client)(nil)
+
+var (
+	// timesInForce are binance's names for ours. Post only isn't a time in force on binance but an order type.
+	timesInForce = map[string]string{
+		exchange.GoodTilCancelled:  "GTC",
+		exchange.ImmediateOrCancel: "IOC",
+		exchange.FillOrKill:        "FOK",
+	}
+	// openStatuses are the order statuses that can still fill, everything else is closed
+	openStatuses = map[string]bool{
+		"NEW":              true,
+		"PARTIALLY_FILLED": true,
+	}
+)
+
+// Name is binance
+func (c *Client) Name() string {
+	return "binance"
+}
+
+// Ping checks binance is up
+func (c *Client) Ping(ctx context.Context) error {
+	var ret struct{}
+	return c.call(ctx, http.MethodGet, "/api/v3/ping", nil, false, &ret)
+}
+
+// GetAccount gets the account the credentials belong to, with its balances
+func (c *Client) GetAccount(ctx context.Context) (AccountResponse, error) {
+	var ret AccountResponse
+	err := c.call(ctx, http.MethodGet, "/api/v3/account", nil, true, &ret)
+	return ret, err
+}
+
+// Account is the binance account the credentials belong to
+func (c *Client) Account(ctx context.Context) (exchange.Account, error) {
+	account, err := c.GetAccount(ctx)
+	if err != nil {
+		return exchange.Account{}, err
+	}
+	return exchange.Account{ID: strconv.FormatInt(account.UID, 10)}, nil
+}
+
+// Balances is everything the account holds. Tether is reported as USD, the same as the markets.
+func (c *Client) Balances(ctx context.Context) ([]exchange.Balance, error) {
+	ret := make([]exchange.Balance, 0)
+	account, err := c.GetAccount(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, balance := range account.Balances {
+		ret = append(ret, exchange.Balance{
+			Currency:  canonicalAsset(balance.Asset),
+			Total:     balance.Free.Add(balance.Locked),
+			Available: balance.Free,
+		})
+	}
+	return ret, nil
+}
+
+// GetExchangeInfo gets the rules for the given binance tickers, or every market when there are none
+func (c *Client) GetExchangeInfo(ctx context.Context, symbols ...string) (ExchangeInfoResponse, error) {
+	params := url.Values{}
+	if len(symbols) == 1 {
+		params.Set("symbol", symbols[0])
+	} else if len(symbols) > 1 {
+		// Several symbols go as a json array
+		list, err := json.Marshal(symbols)
+		if err != nil {
+			return ExchangeInfoResponse{}, err
+		}
+		params.Set("symbols", string(list))
+	}
+	var ret ExchangeInfoResponse
+	err := c.call(ctx, http.MethodGet, "/api/v3/exchangeInfo", params, false, &ret)
+	return ret, err
+}
+
+// Markets is every market binance lists
+func (c *Client) Markets(ctx context.Context) ([]exchange.MarketInfo, error) {
+	ret := make([]exchange.MarketInfo, 0)
+	info, err := c.GetExchangeInfo(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, symbol := range info.Symbols {
+		ret = append(ret, toMarketInfo(symbol))
+	}
+	return ret, nil
+}
+
+// Market is one market's trading rules
+func (c *Client) Market(ctx context.Context, symbol string) (exchange.MarketInfo, error) {
+	native, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	info, err := c.GetExchangeInfo(ctx, native)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	for _, market := range info.Symbols {
+		if market.Symbol == native {
+			return toMarketInfo(market), nil
+		}
+	}
+	return exchange.MarketInfo{}, fmt.Errorf("%w: binance has no %s market", exchange.ErrNotFound, native)
+}
+
+// Ticker is a market's latest prices
+func (c *Client) Ticker(ctx context.Context, symbol string) (exchange.Ticker, error) {
+	native, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	var ticker TickerResponse
+	err = c.call(ctx, http.MethodGet, "/api/v3/ticker/24hr", url.Values{"symbol": {native}}, false, &ticker)
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	return exchange.Ticker{Symbol: symbol, Last: ticker.LastPrice, Bid: ticker.BidPrice, Ask: ticker.AskPrice}, nil
+}
+
+// GetKlines gets a binance ticker's most recent candles, including the one still trading
+func (c *Client) GetKlines(ctx context.Context, symbol string, interval string, limit int) ([]KlineResponse, error) {
+	params := url.Values{"symbol": {symbol}, "interval": {interval}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	ret := make([]KlineResponse, 0)
+	err := c.call(ctx, http.MethodGet, "/api/v3/klines", params, false, &ret)
+	return ret, err
+}
+
+// Candles are a market's recent closed candles, oldest first
+func (c *Client) Candles(ctx context.Context, symbol string, interval string) ([]exchange.Candle, error) {
+	ret := make([]exchange.Candle, 0)
+	native, err := NativeSymbol(symbol)
+	if err != nil {
+		return ret, err
+	}
+	nativeInterval, ok := Intervals[interval]
+	if !ok {
+		return ret, fmt.Errorf("%w: binance has no %s candles", exchange.ErrUnsupported, interval)
+	}
+	klines, err := c.GetKlines(ctx, native, nativeInterval, 0)
+	if err != nil {
+		return ret, err
+	}
+	now := c.now()
+	for _, kline := range klines {
+		// The last kline is still trading, bittrex would leave it out
+		if !kline.CloseTime.Before(now) {
+			continue
+		}
+		ret = append(ret, exchange.Candle{
+			StartsAt: kline.OpenTime,
+			Open:     kline.Open,
+			High:     kline.High,
+			Low:      kline.Low,
+			Close:    kline.Close,
+			Volume:   kline.Volume,
+		})
+	}
+	return ret, nil
+}
+
+// PlaceOrder sends an order to binance. Orders are never retried, the client order id is how a lost one gets
+// found instead.
+func (c *Client) PlaceOrder(ctx context.Context, order exchange.OrderRequest) (exchange.Order, error) {
+	params, err := orderParams(order)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	if order.ClientOrderID == "" {
+		order.ClientOrderID, err = newClientOrderID()
+		if err != nil {
+			return exchange.Order{}, err
+		}
+	}
+	params.Set("newClientOrderId", order.ClientOrderID)
+
+	var sent, answered int32
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			atomic.StoreInt32(&sent, 1)
+		},
+		GotFirstResponseByte: func() {
+			atomic.StoreInt32(&answered, 1)
+		},
+	})
+	resp, err := c.send(ctx, http.MethodPost, "/api/v3/order", params, true)
+	if err != nil {
+		if atomic.LoadInt32(&sent) == 1 && atomic.LoadInt32(&answered) == 0 {
+			return exchange.Order{}, &exchange.UncertainOrderError{Order: order, Err: err}
+		}
+		return exchange.Order{}, err
+	}
+
+	// The order exists from here on, so losing the body leaves us not knowing what it is
+	var placed OrderResponse
+	err = decode(resp, &placed)
+	if err != nil {
+		return exchange.Order{}, &exchange.UncertainOrderError{Order: order, Err: err}
+	}
+	return toOrder(placed, order.Symbol), nil
+}
+
+// GetOrder looks an order up by its binance id
+func (c *Client) GetOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	return c.orderCall(ctx, http.MethodGet, symbol, "orderId", id)
+}
+
+// FindOrder looks an order up by the id we gave it
+func (c *Client) FindOrder(ctx context.Context, symbol string, clientOrderID string) (exchange.Order, error) {
+	return c.orderCall(ctx, http.MethodGet, symbol, "origClientOrderId", clientOrderID)
+}
+
+// CancelOrder stops an order from filling any more
+func (c *Client) CancelOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	return c.orderCall(ctx, http.MethodDelete, symbol, "orderId", id)
+}
+
+func (c *Client) orderCall(ctx context.Context, method string, symbol string, key string, id string) (exchange.Order, error) {
+	native, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	var order OrderResponse
+	err = c.call(ctx, method, "/api/v3/order", url.Values{"symbol": {native}, key: {id}}, true, &order)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(order, symbol), nil
+}
+
+// OpenOrders is every order on a market that can still fill
+func (c *Client) OpenOrders(ctx context.Context, symbol string) ([]exchange.Order, error) {
+	ret := make([]exchange.Order, 0)
+	native, err := NativeSymbol(symbol)
+	if err != nil {
+		return ret, err
+	}
+	orders := make([]OrderResponse, 0)
+	err = c.call(ctx, http.MethodGet, "/api/v3/openOrders", url.Values{"symbol": {native}}, true, &orders)
+	if err != nil {
+		return ret, err
+	}
+	for _, order := range orders {
+		ret = append(ret, toOrder(order, symbol))
+	}
+	return ret, nil
+}
+
+// orderParams is an order in binance's words
+func orderParams(order exchange.OrderRequest) (url.Values, error) {
+	native, err := NativeSymbol(order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{
+		"symbol":           {native},
+		"side":             {order.Side},
+		"type":             {order.Type},
+		"quantity":         {order.Quantity.String()},
+		"newOrderRespType": {"FULL"},
+	}
+	if order.Type == exchange.Limit {
+		params.Set("price", order.Limit.String())
+	}
+	switch {
+	case order.TimeInForce == exchange.PostOnly && order.Type == exchange.Limit:
+		params.Set("type", "LIMIT_MAKER")
+	case order.Type == exchange.Limit:
+		timeInForce, ok := timesInForce[order.TimeInForce]
+		if !ok {
+			return nil, fmt.Errorf("%w: binance has no %s time in force", exchange.ErrUnsupported, order.TimeInForce)
+		}
+		params.Set("timeInForce", timeInForce)
+	case order.Type != exchange.Market:
+		return nil, fmt.Errorf("%w: binance has no %s orders", exchange.ErrUnsupported, order.Type)
+	}
+	return params, nil
+}
+
+func toMarketInfo(symbol SymbolResponse) exchange.MarketInfo {
+	market := exchange.MarketInfo{
+		Symbol: CanonicalSymbol(symbol.BaseAsset, symbol.QuoteAsset),
+		Native: symbol.Symbol,
+		Base:   canonicalAsset(symbol.BaseAsset),
+		Quote:  canonicalAsset(symbol.QuoteAsset),
+		Active: symbol.Status == "TRADING",
+	}
+	for _, filter := range symbol.Filters {
+		switch filter.FilterType {
+		case "PRICE_FILTER":
+			market.TickSize = filter.TickSize
+		case "LOT_SIZE":
+			market.MinQuantity = filter.MinQty
+			market.StepSize = filter.StepSize
+		}
+	}
+	return market
+}
+
+func toOrder(order OrderResponse, symbol string) exchange.Order {
+	ret := exchange.Order{
+		ID:             strconv.FormatInt(order.OrderID, 10),
+		ClientOrderID:  order.ClientOrderID,
+		Symbol:         symbol,
+		Side:           order.Side,
+		Type:           order.Type,
+		TimeInForce:    order.TimeInForce,
+		Quantity:       order.OrigQty,
+		Limit:          order.Price,
+		FilledQuantity: order.ExecutedQty,
+		Proceeds:       order.CummulativeQuoteQty,
+		Commission:     decimal.Zero,
+		Status:         exchange.Closed,
+		CreatedAt:      fromMillis(order.Time),
+		UpdatedAt:      fromMillis(order.UpdateTime),
+	}
+	// Cancels answer with the cancel's own client id, the order's is the original one
+	if order.OrigClientOrderID != "" {
+		ret.ClientOrderID = order.OrigClientOrderID
+	}
+	for canonical, native := range timesInForce {
+		if order.TimeInForce == native {
+			ret.TimeInForce = canonical
+		}
+	}
+	if order.Type == "LIMIT_MAKER" {
+		ret.Type = exchange.Limit
+		ret.TimeInForce = exchange.PostOnly
+	}
+	// New orders only have the time they traded
+	if order.TransactTime != 0 {
+		if ret.CreatedAt.IsZero() {
+			ret.CreatedAt = fromMillis(order.TransactTime)
+		}
+		ret.UpdatedAt = fromMillis(order.TransactTime)
+	}
+	for _, fill := range order.Fills {
+		ret.Commission = ret.Commission.Add(fill.Commission)
+	}
+	if openStatuses[order.Status] {
+		ret.Status = exchange.Open
+	} else {
+		ret.ClosedAt = ret.UpdatedAt
+	}
+	return ret
+}
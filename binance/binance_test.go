@@ -0,0 +1,399 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testAPIKey    = "vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A"
+	testSecretKey = "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"
+)
+
+var testNow = time.Date(2021, 5, 1, 12, 0, 30, 0, time.UTC)
+
+// stubServer answers like binance, and fails any signed call whose key, timestamp or signature is off
+type stubServer struct {
+	t      *testing.T
+	mu     sync.Mutex
+	orders []OrderResponse
+}
+
+func newTestClient(t *testing.T) *Client {
+	stub := &stubServer{t: t}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return NewClient(WithBaseURL(server.URL), WithCredentials(testAPIKey, testSecretKey), WithClock(func() time.Time { return testNow }), WithRateLimiter(nil))
+}
+
+func writeStubError(w http.ResponseWriter, status int, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "msg": msg})
+}
+
+// verify checks a signed request the way binance does. The signature has to be the last parameter and cover
+// everything before it exactly as sent.
+func (s *stubServer) verify(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("X-MBX-APIKEY") != testAPIKey {
+		writeStubError(w, http.StatusUnauthorized, -2015, "Invalid API-key, IP, or permissions for action.")
+		return false
+	}
+	i := strings.LastIndex(r.URL.RawQuery, "&signature=")
+	if i < 0 {
+		writeStubError(w, http.StatusBadRequest, -1102, "Mandatory parameter 'signature' was not sent.")
+		return false
+	}
+	payload, signature := r.URL.RawQuery[:i], r.URL.RawQuery[i+len("&signature="):]
+	if signature != makeSignature(testSecretKey, payload) {
+		writeStubError(w, http.StatusBadRequest, -1022, "Signature for this request is not valid.")
+		return false
+	}
+	timestamp, err := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+	recvWindow, _ := strconv.ParseInt(r.URL.Query().Get("recvWindow"), 10, 64)
+	if err != nil || recvWindow == 0 || testNow.Sub(fromMillis(timestamp)) > time.Duration(recvWindow)*time.Millisecond {
+		writeStubError(w, http.StatusBadRequest, -1021, "Timestamp for this request is outside of the recvWindow.")
+		return false
+	}
+	return true
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	route := r.Method + " " + r.URL.Path
+	switch route {
+	case "GET /api/v3/ping":
+		fmt.Fprint(w, `{}`)
+	case "GET /api/v3/exchangeInfo":
+		if query.Get("symbol") != "" && query.Get("symbol") != "DOGEUSDT" {
+			writeStubError(w, http.StatusBadRequest, -1121, "Invalid symbol.")
+			return
+		}
+		fmt.Fprint(w, `{"timezone":"UTC","symbols":[{"symbol":"DOGEUSDT","status":"TRADING","baseAsset":"DOGE","quoteAsset":"USDT",
+			"filters":[{"filterType":"PRICE_FILTER","minPrice":"0.00000100","maxPrice":"1000.00000000","tickSize":"0.00000100"},
+			{"filterType":"LOT_SIZE","minQty":"1.00000000","maxQty":"90000000.00000000","stepSize":"1.00000000"}]}]}`)
+	case "GET /api/v3/ticker/24hr":
+		fmt.Fprint(w, `{"symbol":"DOGEUSDT","lastPrice":"0.38460000","bidPrice":"0.38450000","askPrice":"0.38470000"}`)
+	case "GET /api/v3/klines":
+		if query.Get("symbol") != "DOGEUSDT" || query.Get("interval") != "1m" {
+			writeStubError(w, http.StatusBadRequest, -1120, "Invalid interval.")
+			return
+		}
+		// The second kline is still trading at testNow
+		fmt.Fprint(w, `[[1619870340000,"0.38300000","0.38500000","0.38200000","0.38400000","102345.00000000",1619870399999,"39300.1",120,"50000.0","19200.0","0"],
+			[1619870400000,"0.38400000","0.38500000","0.38400000","0.38460000","5000.00000000",1619870459999,"1922.5",12,"2500.0","961.2","0"]]`)
+	case "GET /api/v3/account":
+		if s.verify(w, r) {
+			fmt.Fprint(w, `{"uid":354937868,"accountType":"SPOT","canTrade":true,"balances":[{"asset":"DOGE","free":"150.00000000","locked":"50.00000000"},{"asset":"USDT","free":"99.50000000","locked":"0.00000000"}]}`)
+		}
+	case "POST /api/v3/order":
+		if s.verify(w, r) {
+			s.postOrder(w, r)
+		}
+	case "GET /api/v3/order", "DELETE /api/v3/order":
+		if s.verify(w, r) {
+			s.findOrder(w, r)
+		}
+	case "GET /api/v3/openOrders":
+		if s.verify(w, r) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			open := make([]OrderResponse, 0)
+			for _, order := range s.orders {
+				if openStatuses[order.Status] {
+					open = append(open, order)
+				}
+			}
+			json.NewEncoder(w).Encode(open)
+		}
+	default:
+		writeStubError(w, http.StatusNotFound, -1000, "Unknown route "+route)
+	}
+}
+
+func (s *stubServer) postOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	quantity, err := decimal.NewFromString(query.Get("quantity"))
+	if err != nil || query.Get("symbol") != "DOGEUSDT" {
+		writeStubError(w, http.StatusBadRequest, -1100, "Illegal characters found in parameter.")
+		return
+	}
+	price, _ := decimal.NewFromString(query.Get("price"))
+	if quantity.Mul(price).GreaterThan(decimal.NewFromInt(100)) {
+		writeStubError(w, http.StatusBadRequest, -2010, "Account has insufficient balance for requested action.")
+		return
+	}
+	order := OrderResponse{
+		Symbol:              "DOGEUSDT",
+		OrderID:             int64(28 + len(s.orders)),
+		ClientOrderID:       query.Get("newClientOrderId"),
+		Price:               price,
+		OrigQty:             quantity,
+		ExecutedQty:         decimal.Zero,
+		CummulativeQuoteQty: decimal.Zero,
+		Status:              "NEW",
+		TimeInForce:         query.Get("timeInForce"),
+		Type:                query.Get("type"),
+		Side:                query.Get("side"),
+		TransactTime:        testNow.UnixNano() / int64(time.Millisecond),
+		Fills:               []FillResponse{},
+	}
+	// Anything at or above the ask takes right away
+	if order.Type == "MARKET" || order.Side == "BUY" && price.GreaterThanOrEqual(decimal.RequireFromString("0.3847")) {
+		fill := FillResponse{Price: decimal.RequireFromString("0.3847"), Qty: quantity, Commission: quantity.Mul(decimal.RequireFromString("0.001")), CommissionAsset: "DOGE"}
+		order.Fills = append(order.Fills, fill)
+		order.ExecutedQty = quantity
+		order.CummulativeQuoteQty = fill.Price.Mul(quantity)
+		order.Status = "FILLED"
+	}
+	if order.Type == "LIMIT" && order.TimeInForce == "IOC" && order.Status == "NEW" {
+		order.Status = "EXPIRED"
+	}
+	s.orders = append(s.orders, order)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (s *stubServer) findOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	for i, order := range s.orders {
+		if strconv.FormatInt(order.OrderID, 10) != query.Get("orderId") && order.ClientOrderID != query.Get("origClientOrderId") {
+			continue
+		}
+		order.Fills = nil
+		order.Time = order.TransactTime
+		order.UpdateTime = order.TransactTime
+		order.TransactTime = 0
+		if r.Method == http.MethodDelete {
+			order.Status = "CANCELED"
+			s.orders[i].Status = "CANCELED"
+			order.OrigClientOrderID = order.ClientOrderID
+			order.ClientOrderID = "cancel-" + order.ClientOrderID
+		}
+		json.NewEncoder(w).Encode(order)
+		return
+	}
+	writeStubError(w, http.StatusBadRequest, -2013, "Order does not exist.")
+}
+
+func TestMakeSignatureVector(t *testing.T) {
+	// The example from binance's docs
+	query := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	got := makeSignature(testSecretKey, query)
+	if got != "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71" {
+		t.Errorf("Signature was %s", got)
+	}
+}
+
+func TestSymbolMapping(t *testing.T) {
+	native, err := NativeSymbol("DOGE-USD")
+	if err != nil || native != "DOGEUSDT" {
+		t.Errorf("DOGE-USD mapped to %s, %v", native, err)
+	}
+	native, err = NativeSymbol("eth-btc")
+	if err != nil || native != "ETHBTC" {
+		t.Errorf("eth-btc mapped to %s, %v", native, err)
+	}
+	_, err = NativeSymbol("DOGEUSDT")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected a symbol without a quote to fail, got %v", err)
+	}
+	if symbol := CanonicalSymbol("DOGE", "USDT"); symbol != "DOGE-USD" {
+		t.Errorf("DOGE and USDT mapped to %s", symbol)
+	}
+	for key := range Intervals {
+		if key != exchange.Interval1Min && key != exchange.Interval5Min && key != exchange.Interval1Hour && key != exchange.Interval1Day {
+			t.Errorf("Unexpected interval %s", key)
+		}
+	}
+}
+
+func TestMarketDataFromStub(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	err := client.Ping(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	market, err := client.Market(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if market.Symbol != "DOGE-USD" || market.Native != "DOGEUSDT" || market.Quote != "USD" || !market.Active {
+		t.Errorf("Market was %+v", market)
+	}
+	if market.TickSize.String() != "0.000001" || market.StepSize.String() != "1" || market.MinQuantity.String() != "1" {
+		t.Errorf("Market rules were tick %s, step %s, min %s", market.TickSize, market.StepSize, market.MinQuantity)
+	}
+	_, err = client.Market(ctx, "SHIB-USD")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected an unknown market to be not found, got %v", err)
+	}
+
+	ticker, err := client.Ticker(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Symbol != "DOGE-USD" || ticker.Last.String() != "0.3846" || ticker.Ask.String() != "0.3847" {
+		t.Errorf("Ticker was %+v", ticker)
+	}
+
+	candles, err := client.Candles(ctx, "DOGE-USD", exchange.Interval1Min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("Got %d candles, expected only the closed one", len(candles))
+	}
+	if !candles[0].StartsAt.Equal(time.Date(2021, 5, 1, 11, 59, 0, 0, time.UTC)) || candles[0].Close.String() != "0.384" || candles[0].Volume.String() != "102345" {
+		t.Errorf("Candle was %+v", candles[0])
+	}
+	_, err = client.Candles(ctx, "DOGE-USD", "3min")
+	if !errors.Is(err, exchange.ErrUnsupported) {
+		t.Errorf("Expected an unknown interval to be unsupported, got %v", err)
+	}
+}
+
+func TestSignedCallsToStub(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	account, err := client.Account(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != "354937868" {
+		t.Errorf("Account was %s", account.ID)
+	}
+	balances, err := client.Balances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[0].Total.String() != "200" || balances[0].Available.String() != "150" || balances[1].Currency != "USD" {
+		t.Errorf("Balances were %+v", balances)
+	}
+
+	wrongSecret := NewClient(WithBaseURL(client.BaseURL()), WithCredentials(testAPIKey, "not the secret"), WithClock(func() time.Time { return testNow }), WithRateLimiter(nil))
+	_, err = wrongSecret.Account(ctx)
+	if !errors.Is(err, exchange.ErrBadCredentials) {
+		t.Errorf("Expected a bad signature to be bad credentials, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "signature=REDACTED") {
+		t.Errorf("Expected the signature to be left out of %q", err)
+	}
+
+	late := NewClient(WithBaseURL(client.BaseURL()), WithCredentials(testAPIKey, testSecretKey), WithClock(func() time.Time { return testNow.Add(-time.Minute) }), WithRateLimiter(nil))
+	_, err = late.Account(ctx)
+	if !errors.Is(err, exchange.ErrInvalidTimestamp) {
+		t.Errorf("Expected a stale timestamp to be invalid, got %v", err)
+	}
+}
+
+func TestOrdersOnStub(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	resting, err := client.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:      "DOGE-USD",
+		Side:        exchange.Buy,
+		Type:        exchange.Limit,
+		Quantity:    decimal.NewFromInt(100),
+		Limit:       decimal.RequireFromString("0.38"),
+		TimeInForce: exchange.GoodTilCancelled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resting.Status != exchange.Open || resting.TimeInForce != exchange.GoodTilCancelled || resting.Symbol != "DOGE-USD" || resting.ClientOrderID == "" {
+		t.Errorf("Resting order was %+v", resting)
+	}
+
+	taken, err := client.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:        "DOGE-USD",
+		Side:          exchange.Buy,
+		Type:          exchange.Limit,
+		Quantity:      decimal.NewFromInt(50),
+		Limit:         decimal.RequireFromString("0.39"),
+		TimeInForce:   exchange.ImmediateOrCancel,
+		ClientOrderID: "b1e0c3a4-0f43-4a4e-9d7a-2f6a3c9d0e11",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken.Status != exchange.Closed || taken.FilledQuantity.String() != "50" || taken.Proceeds.String() != "19.235" || taken.Commission.String() != "0.05" {
+		t.Errorf("Taken order was %+v", taken)
+	}
+	if !taken.ClosedAt.Equal(testNow) {
+		t.Errorf("Taken order closed at %s", taken.ClosedAt)
+	}
+
+	found, err := client.FindOrder(ctx, "DOGE-USD", "b1e0c3a4-0f43-4a4e-9d7a-2f6a3c9d0e11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != taken.ID {
+		t.Errorf("Found order %s, expected %s", found.ID, taken.ID)
+	}
+	_, err = client.FindOrder(ctx, "DOGE-USD", "never-sent")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected an order binance never saw to be not found, got %v", err)
+	}
+
+	open, err := client.OpenOrders(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].ID != resting.ID {
+		t.Errorf("Open orders were %+v", open)
+	}
+
+	canceled, err := client.CancelOrder(ctx, "DOGE-USD", resting.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Status != exchange.Closed || canceled.ClientOrderID != resting.ClientOrderID {
+		t.Errorf("Canceled order was %+v", canceled)
+	}
+
+	_, err = client.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:      "DOGE-USD",
+		Side:        exchange.Buy,
+		Type:        exchange.Limit,
+		Quantity:    decimal.NewFromInt(1000),
+		Limit:       decimal.RequireFromString("0.38"),
+		TimeInForce: exchange.GoodTilCancelled,
+	})
+	if !errors.Is(err, exchange.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds, got %v", err)
+	}
+}
+
+func TestPostOnlyIsLimitMaker(t *testing.T) {
+	params, err := orderParams(exchange.OrderRequest{Symbol: "DOGE-USD", Side: exchange.Sell, Type: exchange.Limit, Quantity: decimal.NewFromInt(10), Limit: decimal.RequireFromString("0.4"), TimeInForce: exchange.PostOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Get("type") != "LIMIT_MAKER" || params.Get("timeInForce") != "" || params.Get("price") != "0.4" {
+		t.Errorf("Post only order was sent as %s", params.Encode())
+	}
+}
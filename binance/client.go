@@ -0,0 +1,197 @@
+// Package binance trades on binance through the exchange.Exchange interface.
+//
+// Binance writes symbols without a separator and settles dollar markets in USDT, so DOGE-USD is DOGEUSDT
+// here. Signed calls carry a timestamp and an HMAC-SHA256 signature of the query string.
+package binance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cryptofu/ratelimit"
+)
+
+const (
+	// DefaultBaseURL is the live binance api
+	DefaultBaseURL = "https://api.binance.com"
+	// DefaultRecvWindow is how long after its timestamp binance will still take a signed request
+	DefaultRecvWindow = time.Second * 5
+)
+
+// Client talks to the binance api. A client is safe to share between bots.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	secretKey  string
+	recvWindow time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+	limiter    *ratelimit.Limiter
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// NewClient makes a new binance client. Without options it points at the live api with no credentials.
+func NewClient(options ...Option) *Client {
+	client := &Client{
+		baseURL:    DefaultBaseURL,
+		recvWindow: DefaultRecvWindow,
+		httpClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		now: time.Now,
+		// Binance allows 1200 request weight a minute, most of what we call weighs 1 or 2
+		limiter: ratelimit.New(5, 50),
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// WithBaseURL points the client at a different api, like a stub server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithCredentials sets the api key and secret used for signed calls
+func WithCredentials(apiKey string, secretKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+		c.secretKey = secretKey
+	}
+}
+
+// WithRecvWindow changes how long binance will take a signed request after it was made
+func WithRecvWindow(recvWindow time.Duration) Option {
+	return func(c *Client) {
+		c.recvWindow = recvWindow
+	}
+}
+
+// WithHTTPClient swaps out the http client used to make requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithClock swaps out the clock used to timestamp signed requests
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithRateLimiter swaps out the rate limiter. Pass the same limiter to several clients to share it, or nil to turn limiting off.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// BaseURL is the api the client is pointed at
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// call sends a request and decodes the json answer into out. Signed calls get a timestamp and signature added
+// to params, and the api key in a header.
+func (c *Client) call(ctx context.Context, method string, path string, params url.Values, signed bool, out interface{}) error {
+	resp, err := c.send(ctx, method, path, params, signed)
+	if err != nil {
+		return err
+	}
+	return decode(resp, out)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, params url.Values, signed bool) (*http.Response, error) {
+	if c.limiter != nil {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := c.encode(params, signed)
+	endpoint := c.baseURL + path
+	if query != "" {
+		endpoint += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "cryptofu")
+	req.Header.Set("Accept", "application/json")
+	if signed {
+		req.Header.Set("X-MBX-APIKEY", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// encode writes params as a query string, signing it when asked. The signature covers the query exactly as
+// it is sent, so it has to come last.
+func (c *Client) encode(params url.Values, signed bool) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	if !signed {
+		return params.Encode()
+	}
+	params.Set("recvWindow", strconv.FormatInt(int64(c.recvWindow/time.Millisecond), 10))
+	params.Set("timestamp", strconv.FormatInt(c.now().UnixNano()/int64(time.Millisecond), 10))
+	query := params.Encode()
+	return query + "&signature=" + makeSignature(c.secretKey, query)
+}
+
+// makeSignature signs a query the way binance expects https://binance-docs.github.io/apidocs/spot/en/#signed-trade-user_data-and-margin-endpoint-security
+func makeSignature(secretKey string, query string) string {
+	hasher := hmac.New(sha256.New, []byte(secretKey))
+	hasher.Write([]byte(query))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// decode reads a successful response into out. It closes the response body.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, out)
+}
+
+// newClientOrderID makes a random v4 uuid, which fits binance's 36 character limit exactly
+func newClientOrderID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
@@ -0,0 +1,101 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cryptofu/exchange"
+)
+
+var (
+	// codeToErr maps binance's error codes https://binance-docs.github.io/apidocs/spot/en/#error-codes
+	codeToErr = map[int]error{
+		-1003: exchange.ErrThrottled,
+		-1013: exchange.ErrMinTradeRequirementNotMet,
+		-1021: exchange.ErrInvalidTimestamp,
+		-1022: exchange.ErrBadCredentials,
+		-1121: exchange.ErrNotFound,
+		-2013: exchange.ErrNotFound,
+		-2014: exchange.ErrBadCredentials,
+		-2015: exchange.ErrBadCredentials,
+	}
+	statusToErr = map[int]error{
+		http.StatusTooManyRequests: exchange.ErrThrottled,
+		// Binance answers 418 once it has banned an ip for ignoring 429s
+		http.StatusTeapot:       exchange.ErrThrottled,
+		http.StatusUnauthorized: exchange.ErrBadCredentials,
+		http.StatusNotFound:     exchange.ErrNotFound,
+	}
+)
+
+// APIError is a non-success response from binance. Match it with the exchange package's errors, e.g.
+// errors.Is(err, exchange.ErrThrottled).
+type APIError struct {
+	StatusCode int
+	Code       int
+	Msg        string
+	URL        string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("Status Code: %d from %s", e.StatusCode, e.URL)
+	}
+	return fmt.Sprintf("Status Code: %d %d (%s) from %s", e.StatusCode, e.Code, e.Msg, e.URL)
+}
+
+// Is lets errors.Is match the exchange package's errors for the response code and status
+func (e *APIError) Is(target error) bool {
+	// Binance turns down new orders with one code, only the message says why
+	if e.Code == -2010 && target == exchange.ErrInsufficientFunds {
+		return strings.Contains(strings.ToLower(e.Msg), "insufficient balance")
+	}
+	if codeErr, ok := codeToErr[e.Code]; ok && codeErr == target {
+		return true
+	}
+	if statusErr, ok := statusToErr[e.StatusCode]; ok && statusErr == target {
+		return true
+	}
+	return false
+}
+
+// newAPIError reads a failed response into an *APIError. It closes the response body.
+func newAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.URL = redactSignature(resp.Request.URL)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+	var body struct {
+		Code int
+		Msg  string
+	}
+	// Not every failure comes with a json body, the status code is still worth returning
+	if json.Unmarshal(content, &body) == nil {
+		apiErr.Code = body.Code
+		apiErr.Msg = body.Msg
+	}
+	return apiErr
+}
+
+// redactSignature keeps signatures out of error messages and logs
+func redactSignature(u *url.URL) string {
+	query := u.Query()
+	if query.Get("signature") == "" {
+		return u.String()
+	}
+	redacted := *u
+	query.Set("signature", "REDACTED")
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
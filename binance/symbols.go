@@ -0,0 +1,54 @@
+package binance
+
+import (
+	"fmt"
+	"strings"
+
+	"cryptofu/exchange"
+)
+
+var (
+	// Intervals is binance's name for each of the bittrex.CandleIntervals keys
+	Intervals = map[string]string{
+		exchange.Interval1Min:  "1m",
+		exchange.Interval5Min:  "5m",
+		exchange.Interval1Hour: "1h",
+		exchange.Interval1Day:  "1d",
+	}
+	// quoteAssets are the currencies binance settles in place of ours. Dollar markets trade against tether.
+	quoteAssets = map[string]string{
+		"USD": "USDT",
+	}
+)
+
+// NativeSymbol is binance's ticker for a BASE-QUOTE symbol, e.g. DOGE-USD is DOGEUSDT
+func NativeSymbol(symbol string) (string, error) {
+	parts := strings.Split(symbol, "-")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%w: %q isn't a BASE-QUOTE symbol", exchange.ErrNotFound, symbol)
+	}
+	return strings.ToUpper(nativeAsset(parts[0]) + nativeAsset(parts[1])), nil
+}
+
+// CanonicalSymbol is the BASE-QUOTE symbol for a binance market's assets, e.g. DOGE and USDT are DOGE-USD.
+// Binance tickers can't be split on their own, so it takes the assets exchangeInfo lists for the market.
+func CanonicalSymbol(baseAsset string, quoteAsset string) string {
+	return canonicalAsset(baseAsset) + "-" + canonicalAsset(quoteAsset)
+}
+
+func nativeAsset(asset string) string {
+	asset = strings.ToUpper(asset)
+	if native, ok := quoteAssets[asset]; ok {
+		return native
+	}
+	return asset
+}
+
+func canonicalAsset(asset string) string {
+	for canonical, native := range quoteAssets {
+		if native == asset {
+			return canonical
+		}
+	}
+	return asset
+}
@@ -0,0 +1,126 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AccountResponse is what binance knows about the account, including every balance
+type AccountResponse struct {
+	UID         int64
+	AccountType string
+	CanTrade    bool
+	Balances    []BalanceResponse
+}
+
+// BalanceResponse is one asset's balance. Locked is held by open orders.
+type BalanceResponse struct {
+	Asset  string
+	Free   decimal.Decimal
+	Locked decimal.Decimal
+}
+
+// ExchangeInfoResponse lists binance's markets and their rules
+type ExchangeInfoResponse struct {
+	Symbols []SymbolResponse
+}
+
+// SymbolResponse is one market. Its trading rules are in Filters.
+type SymbolResponse struct {
+	Symbol     string
+	Status     string
+	BaseAsset  string
+	QuoteAsset string
+	Filters    []FilterResponse
+}
+
+// FilterResponse is one of a market's trading rules. Which fields are set depends on FilterType.
+type FilterResponse struct {
+	FilterType string
+	MinPrice   decimal.Decimal
+	MaxPrice   decimal.Decimal
+	TickSize   decimal.Decimal
+	MinQty     decimal.Decimal
+	MaxQty     decimal.Decimal
+	StepSize   decimal.Decimal
+}
+
+// TickerResponse is a market's last day of trading
+type TickerResponse struct {
+	Symbol    string
+	LastPrice decimal.Decimal
+	BidPrice  decimal.Decimal
+	AskPrice  decimal.Decimal
+}
+
+// KlineResponse is a candle. Binance sends them as arrays, so it decodes itself.
+type KlineResponse struct {
+	OpenTime  time.Time
+	Open      decimal.Decimal
+	High      decimal.Decimal
+	Low       decimal.Decimal
+	Close     decimal.Decimal
+	Volume    decimal.Decimal
+	CloseTime time.Time
+}
+
+// UnmarshalJSON reads [openTime, open, high, low, close, volume, closeTime, ...]
+func (k *KlineResponse) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	if len(fields) < 7 {
+		return fmt.Errorf("kline has %d fields, expected at least 7", len(fields))
+	}
+	var openTime, closeTime int64
+	for i, target := range []interface{}{&openTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume, &closeTime} {
+		err = json.Unmarshal(fields[i], target)
+		if err != nil {
+			return fmt.Errorf("kline field %d: %w", i, err)
+		}
+	}
+	k.OpenTime = fromMillis(openTime)
+	k.CloseTime = fromMillis(closeTime)
+	return nil
+}
+
+// OrderResponse is an order, as binance answers placing, looking up and canceling one. Fills only come back
+// when the order is placed.
+type OrderResponse struct {
+	Symbol              string
+	OrderID             int64
+	ClientOrderID       string
+	OrigClientOrderID   string
+	Price               decimal.Decimal
+	OrigQty             decimal.Decimal
+	ExecutedQty         decimal.Decimal
+	CummulativeQuoteQty decimal.Decimal
+	Status              string
+	TimeInForce         string
+	Type                string
+	Side                string
+	Time                int64
+	UpdateTime          int64
+	TransactTime        int64
+	Fills               []FillResponse
+}
+
+// FillResponse is part of an order trading
+type FillResponse struct {
+	Price           decimal.Decimal
+	Qty             decimal.Decimal
+	Commission      decimal.Decimal
+	CommissionAsset string
+}
+
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
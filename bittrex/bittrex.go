@@ -2,18 +2,20 @@ package bittrex
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
-	"os"
+	"net/http/httptrace"
+	"sync/atomic"
 	"time"
 
-	"github.com/joho/godotenv"
+	"cryptofu/exchange"
 )
 
 const (
@@ -22,29 +24,6 @@ const (
 )
 
 var (
-	baseURL    = "https://api.bittrex.com"
-	httpClient = http.Client{
-		Timeout: time.Second * 30,
-	}
-	creds = func() Auth {
-		if os.Getenv("TEST") == "true" {
-			return Auth{}
-		}
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatal("💩 Error loading .env file")
-		}
-		auth := Auth{
-			apiKey:    os.Getenv("BIT_KEY"),
-			secretKey: os.Getenv("BIT_SECRET"),
-		}
-		return auth
-	}()
-	// Symbols is a stored association of market symbols to paramatize args
-	Symbols = map[string]string{
-		"Bitcoin": "BTC-USD",
-		"Doge":    "DOGE-USD",
-	}
 	// CandleIntervals is a stored association of candle intervals to paramatize args
 	CandleIntervals = map[string]string{
 		"1min":  "MINUTE_1",
@@ -54,79 +33,121 @@ var (
 	}
 )
 
-// SetBaseURL allows you to set the base url of the bittrex client
-func SetBaseURL(newURL string) {
-	baseURL = newURL
-}
-
 // PokeAPI returns any errors the api throws; nil if the API responds with 0 errors
-func PokeAPI() error {
-	url := fmt.Sprintf("%s/%s/ping", baseURL, APIVersion)
-	response, err := httpClient.Get(url)
+func (c *Client) PokeAPI(ctx context.Context) error {
+	url := fmt.Sprintf("%s/%s/ping", c.baseURL, APIVersion)
+	response, err := c.get(ctx, url, false)
 	if err != nil {
 		return err
 	}
 
 	defer response.Body.Close()
 
-	if response.StatusCode != 200 {
-		return fmt.Errorf("Status Code: %d", response.StatusCode)
-	}
-
 	return nil
 }
 
-func get(url string, authenticate bool) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+func (c *Client) get(ctx context.Context, url string, authenticate bool) (*http.Response, error) {
+	return c.idempotent(ctx, "GET", url, authenticate)
+}
+
+func (c *Client) delete(ctx context.Context, url string, authenticate bool) (*http.Response, error) {
+	return c.idempotent(ctx, "DELETE", url, authenticate)
+}
+
+// idempotent sends a request that is safe to repeat, so failures that might go away are retried with backoff
+func (c *Client) idempotent(ctx context.Context, method string, url string, authenticate bool) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.sendOnce(ctx, method, url, authenticate)
+		if err == nil && resp.StatusCode == 200 {
+			return resp, nil
+		}
+		if err == nil && !retryableStatuses[resp.StatusCode] {
+			return nil, newAPIError(resp)
+		}
+		if ctx.Err() != nil || attempt >= c.retry.MaxAttempts {
+			if err != nil {
+				return nil, err
+			}
+			return nil, newAPIError(resp)
+		}
+
+		delay := c.retry.backoff(attempt)
+		if err == nil {
+			if wait, ok := retryAfter(resp, c.now()); ok {
+				delay = wait
+			}
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
 	}
+}
 
-	addStandardHeaders(req)
-	if authenticate {
-		addAuthHeaders(req)
+func (c *Client) sendOnce(ctx context.Context, method string, url string, authenticate bool) (*http.Response, error) {
+	err := c.waitForLimiter(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := httpClient.Do(req)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Status Code: %d", resp.StatusCode)
+	addStandardHeaders(req)
+	if authenticate {
+		c.addAuthHeaders(req, nil)
 	}
 
-	return resp, nil
+	return c.httpClient.Do(req)
 }
 
-func post(url string, authenticate bool, body interface{}) (*http.Response, error) {
+func (c *Client) post(ctx context.Context, url string, authenticate bool, body interface{}) (*http.Response, error) {
 	marshaledBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(marshaledBody))
+	err = c.waitForLimiter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(marshaledBody))
 	if err != nil {
 		return nil, err
 	}
 
 	addStandardHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
 	if authenticate {
-		addAuthHeaders(req)
+		c.addAuthHeaders(req, marshaledBody)
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Status Code: %d", resp.StatusCode)
+	// Creating things comes back as a 201
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return nil, newAPIError(resp)
 	}
 
 	return resp, nil
 }
 
+func (c *Client) waitForLimiter(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.Wait(ctx)
+}
+
 func addStandardHeaders(req *http.Request) {
 	req.Header.Set("User-Agent", "cryptofu")
 	req.Header.Set("Accept", "application/json")
@@ -135,17 +156,33 @@ func addStandardHeaders(req *http.Request) {
 	req.Header.Add("Cache-Control", "must-revalidate")
 }
 
-func addAuthHeaders(req *http.Request) {
-	timestamp := makeTimestamp()
-	hash := makeHash("")
-	req.Header.Set("Api-Key", creds.apiKey)
+// addAuthHeaders signs a request. body has to be the exact bytes that get sent, nil for requests without one.
+func (c *Client) addAuthHeaders(req *http.Request, body []byte) {
+	timestamp := c.makeTimestamp()
+	hash := makeHash(string(body))
+	req.Header.Set("Api-Key", c.auth.apiKey)
 	req.Header.Set("Api-Timestamp", fmt.Sprintf("%d", timestamp))
 	req.Header.Set("Api-Content-Hash", hash)
-	req.Header.Set("Api-Signature", makeAPISigniture(req, timestamp, hash))
+	if c.subaccountID != "" {
+		req.Header.Set("Api-Subaccount-Id", c.subaccountID)
+	}
+	req.Header.Set("Api-Signature", makeAPISigniture(c.auth.secretKey, timestamp, req.URL.String(), req.Method, hash, c.subaccountID))
+}
+
+func (c *Client) makeTimestamp() int64 {
+	return c.now().UTC().UnixNano() / int64(time.Millisecond)
 }
 
-func makeTimestamp() int64 {
-	return time.Now().UTC().UnixNano() / int64(time.Millisecond)
+// newClientOrderID makes a random v4 uuid
+func newClientOrderID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
 }
 
 func makeHash(value string) string {
@@ -154,20 +191,21 @@ func makeHash(value string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
-func makeAPISigniture(req *http.Request, timestamp int64, contentHash string) string {
-	// Empty string for subaccount ID https://bittrex.github.io/api/v3#api-signature
-	preSign := fmt.Sprintf("%d%s%s%s%s", timestamp, req.URL, req.Method, contentHash, "")
+// makeAPISigniture signs a request the way bittrex expects https://bittrex.github.io/api/v3#api-signature
+// subaccountID is an empty string when not trading as a subaccount.
+func makeAPISigniture(secretKey string, timestamp int64, uri string, method string, contentHash string, subaccountID string) string {
+	preSign := fmt.Sprintf("%d%s%s%s%s", timestamp, uri, method, contentHash, subaccountID)
 
-	hasher := hmac.New(sha512.New, []byte(creds.secretKey))
+	hasher := hmac.New(sha512.New, []byte(secretKey))
 	hasher.Write([]byte(preSign))
 
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
 // GetMarket gets the daily market values for a symbol
-func GetMarket(symbol string) (MarketResponse, error) {
-	url := fmt.Sprintf("%s/%s/markets/%s/summary", baseURL, APIVersion, symbol)
-	resp, err := get(url, false)
+func (c *Client) GetMarket(ctx context.Context, symbol string) (MarketResponse, error) {
+	url := fmt.Sprintf("%s/%s/markets/%s/summary", c.baseURL, APIVersion, symbol)
+	resp, err := c.get(ctx, url, false)
 	if err != nil {
 		return MarketResponse{}, err
 	}
@@ -189,9 +227,9 @@ func GetMarket(symbol string) (MarketResponse, error) {
 }
 
 // GetTicker gets the current market ticker for a symbol
-func GetTicker(symbol string) (TickerResponse, error) {
-	url := fmt.Sprintf("%s/%s/markets/%s/ticker", baseURL, APIVersion, symbol)
-	resp, err := get(url, false)
+func (c *Client) GetTicker(ctx context.Context, symbol string) (TickerResponse, error) {
+	url := fmt.Sprintf("%s/%s/markets/%s/ticker", c.baseURL, APIVersion, symbol)
+	resp, err := c.get(ctx, url, false)
 	if err != nil {
 		return TickerResponse{}, err
 	}
@@ -213,10 +251,10 @@ func GetTicker(symbol string) (TickerResponse, error) {
 }
 
 // GetCandles gets recent candles for a specific market and interval
-func GetCandles(symbol string, interval string) ([]CandleResponse, error) {
+func (c *Client) GetCandles(ctx context.Context, symbol string, interval string) ([]CandleResponse, error) {
 	defaultRes := make([]CandleResponse, 0)
-	url := fmt.Sprintf("%s/%s/markets/%s/candles/%s/recent", baseURL, APIVersion, symbol, interval)
-	resp, err := get(url, false)
+	url := fmt.Sprintf("%s/%s/markets/%s/candles/%s/recent", c.baseURL, APIVersion, symbol, interval)
+	resp, err := c.get(ctx, url, false)
 	if err != nil {
 		return defaultRes, err
 	}
@@ -237,12 +275,17 @@ func GetCandles(symbol string, interval string) ([]CandleResponse, error) {
 	return ret, nil
 }
 
-// GetHistoricalCandles gets historical candles for a specific market and interval and year and month and day
-func GetHistoricalCandles(symbol string, interval string, year int, month int, day int) ([]CandleResponse, error) {
+// GetHistoricalCandles gets historical candles for a specific market and interval and year and month and day.
+// Bittrex keeps minute candles by day, hourly candles by month and daily candles by year, so the parts finer
+// than the interval's bucket are ignored.
+func (c *Client) GetHistoricalCandles(ctx context.Context, symbol string, interval string, year int, month int, day int) ([]CandleResponse, error) {
 	defaultRes := make([]CandleResponse, 0)
-	// This will never be sent to the test server
-	url := fmt.Sprintf("https://api.bittrex.com/%s/markets/%s/candles/%s/historical/%d/%d/%d", APIVersion, symbol, interval, year, month, day)
-	resp, err := get(url, false)
+	bucket, err := historicalBucketPath(interval, year, month, day)
+	if err != nil {
+		return defaultRes, err
+	}
+	url := fmt.Sprintf("%s/%s/markets/%s/candles/%s/historical/%s", c.baseURL, APIVersion, symbol, interval, bucket)
+	resp, err := c.get(ctx, url, false)
 	if err != nil {
 		return defaultRes, err
 	}
@@ -264,9 +307,9 @@ func GetHistoricalCandles(symbol string, interval string, year int, month int, d
 }
 
 // GetAccount gets your account info
-func GetAccount() (AccountResponse, error) {
-	url := fmt.Sprintf("%s/%s/account", baseURL, APIVersion)
-	resp, err := get(url, true)
+func (c *Client) GetAccount(ctx context.Context) (AccountResponse, error) {
+	url := fmt.Sprintf("%s/%s/account", c.baseURL, APIVersion)
+	resp, err := c.get(ctx, url, true)
 	if err != nil {
 		return AccountResponse{}, err
 	}
@@ -288,9 +331,9 @@ func GetAccount() (AccountResponse, error) {
 }
 
 // GetBalances gets the balances of all currencies in your account
-func GetBalances() (BalancesResponce, error) {
-	url := fmt.Sprintf("%s/%s/balances", baseURL, APIVersion)
-	resp, err := get(url, true)
+func (c *Client) GetBalances(ctx context.Context) (BalancesResponce, error) {
+	url := fmt.Sprintf("%s/%s/balances", c.baseURL, APIVersion)
+	resp, err := c.get(ctx, url, true)
 	if err != nil {
 		return BalancesResponce{}, err
 	}
@@ -311,29 +354,48 @@ func GetBalances() (BalancesResponce, error) {
 	return ret, nil
 }
 
-// Order requests a new order
-func Order(orderDetails NewOrder) (OrderResponse, error) {
-	url := fmt.Sprintf("%s/%s/orders", baseURL, APIVersion)
-	resp, err := post(url, true, orderDetails)
+// Order requests a new order. If the request is cut off after it may have reached bittrex the error is an
+// *exchange.UncertainOrderError, and the order has to be reconciled before it is tried again.
+func (c *Client) Order(ctx context.Context, orderDetails NewOrder) (OrderResponse, error) {
+	url := fmt.Sprintf("%s/%s/orders", c.baseURL, APIVersion)
+	// Orders are never retried, the client order id is how a lost one gets found instead
+	if orderDetails.ClientOrderID == "" {
+		clientOrderID, err := newClientOrderID()
+		if err != nil {
+			return OrderResponse{}, err
+		}
+		orderDetails.ClientOrderID = clientOrderID
+	}
+	var sent, answered int32
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			atomic.StoreInt32(&sent, 1)
+		},
+		GotFirstResponseByte: func() {
+			atomic.StoreInt32(&answered, 1)
+		},
+	})
+	resp, err := c.post(ctx, url, true, orderDetails)
 	if err != nil {
+		if atomic.LoadInt32(&sent) == 1 && atomic.LoadInt32(&answered) == 0 {
+			return OrderResponse{}, &exchange.UncertainOrderError{Order: toOrderRequest(orderDetails), Err: err}
+		}
 		return OrderResponse{}, err
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 201 {
-		return OrderResponse{}, fmt.Errorf("Status Code: %d", resp.StatusCode)
-	}
-
+	// post only lets through a 200 or a 201, and either way the order exists from here on, so losing the
+	// body leaves us not knowing what it is
 	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return OrderResponse{}, err
+		return OrderResponse{}, &exchange.UncertainOrderError{Order: toOrderRequest(orderDetails), Err: err}
 	}
 
 	var ret OrderResponse
 	err = json.Unmarshal(content, &ret)
 	if err != nil {
-		return OrderResponse{}, err
+		return OrderResponse{}, &exchange.UncertainOrderError{Order: toOrderRequest(orderDetails), Err: err}
 	}
 
 	return ret, nil
@@ -0,0 +1,204 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cryptofu/exchange"
+)
+
+func newAccountServer(accountID string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Api-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"subaccountId":"","accountId":"%s"}`, accountID)
+	}))
+}
+
+func TestClientsAreIndependent(t *testing.T) {
+	mochi := newAccountServer("mochi")
+	defer mochi.Close()
+	bao := newAccountServer("bao")
+	defer bao.Close()
+
+	mochiClient := NewClient(WithBaseURL(mochi.URL), WithCredentials("mochi-key", "mochi-secret"))
+	baoClient := NewClient(WithBaseURL(bao.URL), WithCredentials("bao-key", "bao-secret"))
+
+	account, err := mochiClient.GetAccount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.AccountID != "mochi" {
+		t.Errorf("Was %s, expected mochi", account.AccountID)
+	}
+	account, err = baoClient.GetAccount(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.AccountID != "bao" {
+		t.Errorf("Was %s, expected bao", account.AccountID)
+	}
+}
+
+func TestClientWithoutCredentials(t *testing.T) {
+	server := newAccountServer("mochi")
+	defer server.Close()
+
+	_, err := NewClient(WithBaseURL(server.URL)).GetAccount(context.Background())
+	if err == nil {
+		t.Error("Expected an error without credentials")
+	}
+}
+
+func TestOrderCutOffIsUncertain(t *testing.T) {
+	release := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(WithBaseURL(server.URL)).Order(ctx, NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY"})
+	if !errors.Is(err, ErrOrderUncertain) {
+		t.Errorf("Was %v, expected an uncertain order", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Was %v, expected the deadline to be exposed", err)
+	}
+	var uncertain *exchange.UncertainOrderError
+	if !errors.As(err, &uncertain) || uncertain.Order.Symbol != "DOGE-USD" || uncertain.Order.ClientOrderID == "" {
+		t.Errorf("Was %#v, expected the order to look for", err)
+	}
+}
+
+func TestOrderNeverSentIsNotUncertain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("The order should never have been sent")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewClient(WithBaseURL(server.URL)).Order(ctx, NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY"})
+	if err == nil || errors.Is(err, ErrOrderUncertain) {
+		t.Errorf("Was %v, expected a plain error", err)
+	}
+}
+
+func TestOrderRejectedIsNotUncertain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(WithBaseURL(server.URL)).Order(context.Background(), NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY"})
+	if err == nil || errors.Is(err, ErrOrderUncertain) {
+		t.Errorf("Was %v, expected a plain error", err)
+	}
+}
+
+func TestOrderAnsweredWithA200IsPlaced(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"order-1","marketSymbol":"DOGE-USD","status":"OPEN"}`))
+	}))
+	defer server.Close()
+
+	order, err := NewClient(WithBaseURL(server.URL)).Order(context.Background(), NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != "order-1" {
+		t.Errorf("Got %+v, expected order-1", order)
+	}
+}
+
+func TestAPIErrorDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/account":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"INVALID_SIGNATURE"}`))
+		case "/v3/orders":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INSUFFICIENT_FUNDS","detail":"not enough USD"}`))
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+
+	_, err := client.GetAccount(context.Background())
+	if !errors.Is(err, ErrInvalidSignature) || !errors.Is(err, ErrBadCredentials) {
+		t.Errorf("Was %v, expected a bad signature", err)
+	}
+
+	_, err = client.Order(context.Background(), NewOrder{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Was %v, expected an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "INSUFFICIENT_FUNDS" || apiErr.Detail != "not enough USD" {
+		t.Errorf("Decoded %+v", apiErr)
+	}
+	if apiErr.URL != server.URL+"/v3/orders" {
+		t.Errorf("URL was %s", apiErr.URL)
+	}
+	if !errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrThrottled) {
+		t.Errorf("Was %v, expected only insufficient funds", err)
+	}
+
+	err = client.PokeAPI(context.Background())
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("Was %v, expected a 429 without a body to be throttled", err)
+	}
+}
+
+func decodeBody(t *testing.T, r *http.Request, into interface{}) {
+	err := json.NewDecoder(r.Body).Decode(into)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestModelsDecodeMoneyAndTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/balances":
+			w.Write([]byte(`[{"currencySymbol":"DOGE","total":"1234.56780000","available":"0.00000001","updatedAt":"2021-01-28T18:07:49.17Z"}]`))
+		default:
+			w.Write([]byte(`[{"startsAt":"2020-06-01T00:01:00Z","open":"0.00261","high":"0.00263","low":"0.0026","close":"0.00262","volume":"158240.5","quoteVolume":"413.27"}]`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL))
+
+	balances, err := client.GetBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[0].Total.String() != "1234.5678" || balances[0].Available.String() != "0.00000001" {
+		t.Errorf("Balance was %s with %s available", balances[0].Total, balances[0].Available)
+	}
+	if balances[0].UpdatedAt.Nanosecond() != 170000000 {
+		t.Errorf("Updated at %s", balances[0].UpdatedAt)
+	}
+
+	candles, err := client.GetCandles(context.Background(), "DOGE-USD", "MINUTE_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !candles[0].StartsAt.Equal(time.Date(2020, 6, 1, 0, 1, 0, 0, time.UTC)) || candles[0].Close.String() != "0.00262" {
+		t.Errorf("Candle was %+v", candles[0])
+	}
+}
@@ -0,0 +1,247 @@
+package bittrex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+var (
+	// ErrCassetteMismatch means a replayed client asked for something other than what was recorded next
+	ErrCassetteMismatch = errors.New("request does not match the cassette")
+	// redactedHeaders matches the names of headers that are left out of cassettes so they can be shared. It goes
+	// by what credential headers tend to be called rather than listing bittrex's, so a recorder wrapped around any
+	// client's transport keeps its keys and signatures out too.
+	redactedHeaders = regexp.MustCompile(`(?i)key|sign|auth|secret|pass|subaccount`)
+)
+
+// Redacted is what credentials are replaced with in a cassette
+const Redacted = "REDACTED"
+
+// Cassette is a recorded session of exchange traffic. Only headers are redacted: urls and bodies are stored
+// verbatim, so a client that puts credentials in its query string or body will leave them in the cassette.
+type Cassette struct {
+	Interactions []Interaction
+}
+
+// Interaction is one request and what came back. Err is set instead of Response when there was no answer,
+// and Sent says whether the request had gone out by then.
+type Interaction struct {
+	Request  CassetteRequest
+	Response *CassetteResponse `json:",omitempty"`
+	Err      string            `json:",omitempty"`
+	Sent     bool
+}
+
+// CassetteRequest is a recorded request with its credential headers redacted. URL and Body are as sent.
+type CassetteRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+}
+
+// CassetteResponse is a recorded response, body and all. Bodies that aren't valid utf-8 are kept in RawBody
+// instead so they survive the trip through json byte for byte.
+type CassetteResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	RawBody    []byte `json:",omitempty"`
+}
+
+func newCassetteResponse(resp *http.Response, body []byte) *CassetteResponse {
+	ret := &CassetteResponse{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+	if utf8.Valid(body) {
+		ret.Body = string(body)
+	} else {
+		ret.RawBody = body
+	}
+	return ret
+}
+
+func (c *CassetteResponse) body() []byte {
+	if c.RawBody != nil {
+		return c.RawBody
+	}
+	return []byte(c.Body)
+}
+
+// LoadCassette reads a cassette saved by Recorder.Save
+func LoadCassette(name string) (Cassette, error) {
+	var cassette Cassette
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return cassette, err
+	}
+	err = json.Unmarshal(content, &cassette)
+	if err != nil {
+		return cassette, fmt.Errorf("reading cassette %s: %w", name, err)
+	}
+	return cassette, nil
+}
+
+// Recorder is a transport that passes requests on and writes down everything that goes by. Use it with
+// WithHTTPClient(&http.Client{Transport: recorder}).
+type Recorder struct {
+	next     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records what goes through next, or http.DefaultTransport if next is nil
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next}
+}
+
+// RoundTrip sends a request on and records it
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	interaction := Interaction{Request: CassetteRequest{Method: req.Method, URL: req.URL.String(), Header: redact(req.Header), Body: string(body)}}
+
+	var sent int32
+	ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			atomic.StoreInt32(&sent, 1)
+		},
+	})
+	resp, err := r.next.RoundTrip(req.WithContext(ctx))
+	if err == nil {
+		content, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			// Cut off partway through the body, which the client would have seen as no answer
+			resp, err = nil, readErr
+		} else {
+			resp.Body = ioutil.NopCloser(bytes.NewReader(content))
+			interaction.Response = newCassetteResponse(resp, content)
+		}
+	}
+	if err != nil {
+		interaction.Err = err.Error()
+	}
+	interaction.Sent = atomic.LoadInt32(&sent) == 1
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, err
+}
+
+// Cassette is everything recorded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction{}, r.cassette.Interactions...)}
+}
+
+// Save writes everything recorded so far to a file
+func (r *Recorder) Save(name string) error {
+	content, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, content, 0644)
+}
+
+// Replayer is a transport that answers with a cassette instead of the network. Requests have to come in the
+// order they were recorded, with the same method and url. Bodies and headers aren't compared, since
+// timestamps, signatures and client order ids change from run to run.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// NewReplayer plays a cassette back from the start
+func NewReplayer(cassette Cassette) *Replayer {
+	return &Replayer{interactions: cassette.Interactions}
+}
+
+// RoundTrip answers a request with the next recorded interaction
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	p.mu.Lock()
+	if p.next >= len(p.interactions) {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s %s came after the last recorded request", ErrCassetteMismatch, req.Method, req.URL)
+	}
+	interaction := p.interactions[p.next]
+	if interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: got %s %s, expected %s %s", ErrCassetteMismatch, req.Method, req.URL, interaction.Request.Method, interaction.Request.URL)
+	}
+	p.next++
+	p.mu.Unlock()
+
+	// Let anyone tracing the request see it go out and come back the way it did when it was recorded
+	trace := httptrace.ContextClientTrace(req.Context())
+	if trace != nil && trace.WroteRequest != nil && interaction.Sent {
+		trace.WroteRequest(httptrace.WroteRequestInfo{})
+	}
+	if interaction.Response == nil {
+		return nil, errors.New(interaction.Err)
+	}
+	if trace != nil && trace.GotFirstResponseByte != nil {
+		trace.GotFirstResponseByte()
+	}
+	body := interaction.Response.body()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining is how many recorded interactions haven't been played yet
+func (p *Replayer) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.interactions) - p.next
+}
+
+// readRequestBody reads a request body and puts it back so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func redact(header http.Header) http.Header {
+	ret := header.Clone()
+	for name := range ret {
+		if redactedHeaders.MatchString(name) {
+			ret[name] = []string{Redacted}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,158 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cassetteClient(baseURL string, transport http.RoundTripper) *Client {
+	return NewClient(
+		WithBaseURL(baseURL),
+		WithCredentials("my-key", "my-secret"),
+		WithSubaccountID("my-subaccount"),
+		WithHTTPClient(&http.Client{Timeout: time.Second * 5, Transport: transport}),
+		WithRateLimiter(nil),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	exchange := newTestExchange(t)
+	server := httptest.NewServer(exchange.Handler())
+	recorder := NewRecorder(nil)
+	client := cassetteClient(server.URL, recorder)
+	ctx := context.Background()
+
+	recordedTicker, err := client.GetTicker(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordedOrder, err := client.Order(ctx, dogeOrder("BUY", "LIMIT", "100", "0.06", "IMMEDIATE_OR_CANCEL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetOrder(ctx, "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected not found, got %v", err)
+	}
+	server.Close()
+
+	name := filepath.Join(t.TempDir(), "session.json")
+	err = recorder.Save(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"my-key", "my-subaccount"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("Cassette still has %q in it", secret)
+		}
+	}
+
+	cassette, err := LoadCassette(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 3 || cassette.Interactions[1].Request.Header.Get("Api-Signature") != Redacted {
+		t.Fatalf("Cassette was %+v", cassette)
+	}
+	replayer := NewReplayer(cassette)
+	// The server is gone, so everything has to come off the cassette
+	replayed := cassetteClient(server.URL, replayer)
+
+	ticker, err := replayed.GetTicker(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ticker) != fmt.Sprint(recordedTicker) {
+		t.Errorf("Replayed %+v, recorded %+v", ticker, recordedTicker)
+	}
+	order, err := replayed.Order(ctx, dogeOrder("BUY", "LIMIT", "100", "0.06", "IMMEDIATE_OR_CANCEL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != recordedOrder.ID || order.ClientOrderID != recordedOrder.ClientOrderID {
+		t.Errorf("Replayed order %s, recorded %s", order.ID, recordedOrder.ID)
+	}
+	_, err = replayed.GetOrder(ctx, "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the replayed 404 to be not found, got %v", err)
+	}
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d interactions were never played", replayer.Remaining())
+	}
+	_, err = replayed.GetTicker(ctx, "DOGE-USD")
+	if !errors.Is(err, ErrCassetteMismatch) {
+		t.Errorf("Expected running off the end of the cassette to be a mismatch, got %v", err)
+	}
+}
+
+func TestRedactCoversOtherVenuesHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-MBX-APIKEY", "binance-key")
+	header.Set("CB-ACCESS-KEY", "coinbase-key")
+	header.Set("CB-ACCESS-SIGN", "coinbase-signature")
+	header.Set("CB-ACCESS-PASSPHRASE", "coinbase-passphrase")
+	header.Set("Authorization", "Bearer token")
+	header.Set("Content-Type", "application/json")
+
+	redacted := redact(header)
+	for name := range header {
+		if name == "Content-Type" {
+			continue
+		}
+		if redacted.Get(name) != Redacted {
+			t.Errorf("%s was %q", name, redacted.Get(name))
+		}
+	}
+	if redacted.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type was %q", redacted.Get("Content-Type"))
+	}
+	if header.Get("CB-ACCESS-SIGN") != "coinbase-signature" {
+		t.Error("Redacting changed the request's own headers")
+	}
+}
+
+func TestReplayOutOfOrderIsAMismatch(t *testing.T) {
+	cassette := Cassette{Interactions: []Interaction{{
+		Request:  CassetteRequest{Method: "GET", URL: "http://recorded/v3/ping"},
+		Response: &CassetteResponse{StatusCode: http.StatusOK, Body: `{"serverTime": 1}`},
+		Sent:     true,
+	}}}
+	client := cassetteClient("http://recorded", NewReplayer(cassette))
+
+	_, err := client.GetTicker(context.Background(), "DOGE-USD")
+	if !errors.Is(err, ErrCassetteMismatch) {
+		t.Errorf("Expected a mismatch, got %v", err)
+	}
+}
+
+func TestReplayedCutOffOrderIsStillUncertain(t *testing.T) {
+	exchange := newTestExchange(t)
+	exchange.SetFaults(FaultProfile{Endpoints: map[string][]Fault{"POST /v3/orders": {{Kind: FaultDrop, Probability: 1}}}})
+	server := httptest.NewServer(exchange.Handler())
+	recorder := NewRecorder(nil)
+	ctx := context.Background()
+
+	_, err := cassetteClient(server.URL, recorder).Order(ctx, dogeOrder("BUY", "LIMIT", "100", "0.06", "IMMEDIATE_OR_CANCEL"))
+	server.Close()
+	if !errors.Is(err, ErrOrderUncertain) {
+		t.Fatalf("Expected the dropped order to be uncertain, got %v", err)
+	}
+
+	_, err = cassetteClient(server.URL, NewReplayer(recorder.Cassette())).Order(ctx, dogeOrder("BUY", "LIMIT", "100", "0.06", "IMMEDIATE_OR_CANCEL"))
+	if !errors.Is(err, ErrOrderUncertain) {
+		t.Errorf("Expected the replayed order to be uncertain too, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package bittrex
+
+import (
+	"net/http"
+	"time"
+
+	"cryptofu/ratelimit"
+)
+
+const (
+	// DefaultBaseURL is the live bittrex api
+	DefaultBaseURL = "https://api.bittrex.com"
+)
+
+// Client talks to the bittrex api. A client is safe to share between bots.
+type Client struct {
+	baseURL      string
+	socketURL    string
+	auth         Auth
+	subaccountID string
+	httpClient   *http.Client
+	now          func() time.Time
+	limiter      *ratelimit.Limiter
+	retry        RetryPolicy
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// NewClient makes a new bittrex client. Without options it points at the live api with no credentials.
+func NewClient(options ...Option) *Client {
+	client := &Client{
+		baseURL:   DefaultBaseURL,
+		socketURL: DefaultSocketURL,
+		httpClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		now: time.Now,
+		// Bittrex allows 60 calls a minute
+		limiter: ratelimit.New(1, 60),
+		retry: RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond * 500,
+			MaxDelay:    time.Second * 10,
+		},
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// WithBaseURL points the client at a different api, like the mock server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithSocketURL points streams at a different websocket, like the mock stream server
+func WithSocketURL(socketURL string) Option {
+	return func(c *Client) {
+		c.socketURL = socketURL
+	}
+}
+
+// WithCredentials sets the api key and secret used for authenticated calls
+func WithCredentials(apiKey string, secretKey string) Option {
+	return func(c *Client) {
+		c.auth = Auth{
+			apiKey:    apiKey,
+			secretKey: secretKey,
+		}
+	}
+}
+
+// WithSubaccountID makes authenticated calls on behalf of a subaccount
+func WithSubaccountID(subaccountID string) Option {
+	return func(c *Client) {
+		c.subaccountID = subaccountID
+	}
+}
+
+// WithHTTPClient swaps out the http client used to make requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithClock swaps out the clock used to timestamp authenticated requests
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithRateLimiter swaps out the rate limiter. Pass the same limiter to several clients to share it, or nil to turn limiting off.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithRetryPolicy changes how failed GETs are retried
+func WithRetryPolicy(retry RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// BaseURL is the api the client is pointed at
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
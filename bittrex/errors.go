@@ -0,0 +1,111 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"cryptofu/exchange"
+)
+
+// Errors every venue has are the exchange package's, so callers can match them without knowing which venue
+// they are talking to
+var (
+	// ErrThrottled means bittrex is rate limiting us
+	ErrThrottled = exchange.ErrThrottled
+	// ErrInsufficientFunds means the account can't cover an order
+	ErrInsufficientFunds = exchange.ErrInsufficientFunds
+	// ErrMinTradeRequirementNotMet means an order was smaller than the market allows
+	ErrMinTradeRequirementNotMet = exchange.ErrMinTradeRequirementNotMet
+	// ErrInvalidSignature means bittrex could not verify a request signature
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrAPIKeyInvalid means bittrex does not recognize the api key
+	ErrAPIKeyInvalid = errors.New("api key invalid")
+	// ErrBadCredentials matches any error caused by the key, secret or signature
+	ErrBadCredentials = exchange.ErrBadCredentials
+	// ErrNotFound means the thing asked for does not exist
+	ErrNotFound = exchange.ErrNotFound
+	// ErrMarketOffline means a market isn't taking orders
+	ErrMarketOffline = exchange.ErrMarketOffline
+	// ErrInvalidOrder means an order was turned down before it was sent
+	ErrInvalidOrder = exchange.ErrInvalidOrder
+	// ErrInvalidTimestamp means our clock and bittrex's disagree by too much to trust a signed request
+	ErrInvalidTimestamp = exchange.ErrInvalidTimestamp
+	// ErrOrderUncertain means an order request was cut off after it may have reached bittrex
+	ErrOrderUncertain = exchange.ErrOrderUncertain
+
+	codeToErr = map[string]error{
+		"THROTTLED":                     ErrThrottled,
+		"INSUFFICIENT_FUNDS":            ErrInsufficientFunds,
+		"MIN_TRADE_REQUIREMENT_NOT_MET": ErrMinTradeRequirementNotMet,
+		"INVALID_SIGNATURE":             ErrInvalidSignature,
+		"APIKEY_INVALID":                ErrAPIKeyInvalid,
+		"NOT_FOUND":                     ErrNotFound,
+		"MARKET_OFFLINE":                ErrMarketOffline,
+		"INVALID_TIMESTAMP":             ErrInvalidTimestamp,
+	}
+	statusToErr = map[int]error{
+		http.StatusTooManyRequests: ErrThrottled,
+		http.StatusUnauthorized:    ErrBadCredentials,
+		http.StatusNotFound:        ErrNotFound,
+	}
+)
+
+// APIError is a non-success response from bittrex. Match the code with errors.Is, e.g. errors.Is(err, ErrThrottled).
+type APIError struct {
+	StatusCode int
+	Code       string
+	Detail     string
+	URL        string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("Status Code: %d from %s", e.StatusCode, e.URL)
+	}
+	if e.Detail == "" {
+		return fmt.Sprintf("Status Code: %d %s from %s", e.StatusCode, e.Code, e.URL)
+	}
+	return fmt.Sprintf("Status Code: %d %s (%s) from %s", e.StatusCode, e.Code, e.Detail, e.URL)
+}
+
+// Is lets errors.Is match the sentinel errors for the response code and status
+func (e *APIError) Is(target error) bool {
+	if target == ErrBadCredentials {
+		return e.Is(ErrInvalidSignature) || e.Is(ErrAPIKeyInvalid) || e.StatusCode == http.StatusUnauthorized
+	}
+	if codeErr, ok := codeToErr[e.Code]; ok && codeErr == target {
+		return true
+	}
+	if statusErr, ok := statusToErr[e.StatusCode]; ok && statusErr == target {
+		return true
+	}
+	return false
+}
+
+// newAPIError reads a failed response into an *APIError. It closes the response body.
+func newAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.URL = resp.Request.URL.String()
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+	var body struct {
+		Code   string
+		Detail string
+	}
+	// Not every failure comes with a json body, the status code is still worth returning
+	if json.Unmarshal(content, &body) == nil {
+		apiErr.Code = body.Code
+		apiErr.Detail = body.Detail
+	}
+	return apiErr
+}
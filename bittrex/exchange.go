@@ -0,0 +1,227 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ exchange.Exchange = (*Exchange)(nil)
+
+const (
+	// QuantityPrecision is how many decimal places bittrex takes on an order quantity
+	QuantityPrecision = 8
+)
+
+// Exchange is bittrex behind the exchange.Exchange interface. Bittrex already writes symbols BASE-QUOTE and
+// uses the same order words, so mostly it is the intervals and shapes that change.
+type Exchange struct {
+	client *Client
+}
+
+// NewExchange trades on bittrex through client
+func NewExchange(client *Client) *Exchange {
+	return &Exchange{client: client}
+}
+
+// Client is the bittrex client underneath, for what the interface doesn't cover
+func (e *Exchange) Client() *Client {
+	return e.client
+}
+
+// Name is bittrex
+func (e *Exchange) Name() string {
+	return "bittrex"
+}
+
+// Ping checks bittrex is up
+func (e *Exchange) Ping(ctx context.Context) error {
+	return e.client.PokeAPI(ctx)
+}
+
+// Account is the bittrex account the credentials belong to
+func (e *Exchange) Account(ctx context.Context) (exchange.Account, error) {
+	account, err := e.client.GetAccount(ctx)
+	if err != nil {
+		return exchange.Account{}, err
+	}
+	return exchange.Account{ID: account.AccountID}, nil
+}
+
+// Balances is everything the account holds
+func (e *Exchange) Balances(ctx context.Context) ([]exchange.Balance, error) {
+	ret := make([]exchange.Balance, 0)
+	balances, err := e.client.GetBalances(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, balance := range balances {
+		ret = append(ret, exchange.Balance{Currency: balance.CurrencySymbol, Total: balance.Total, Available: balance.Available})
+	}
+	return ret, nil
+}
+
+// Markets is every market bittrex lists
+func (e *Exchange) Markets(ctx context.Context) ([]exchange.MarketInfo, error) {
+	ret := make([]exchange.MarketInfo, 0)
+	markets, err := e.client.GetMarkets(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, market := range markets {
+		ret = append(ret, toMarketInfo(market))
+	}
+	return ret, nil
+}
+
+// Market is one market's trading rules
+func (e *Exchange) Market(ctx context.Context, symbol string) (exchange.MarketInfo, error) {
+	market, err := e.client.GetMarketInfo(ctx, symbol)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	return toMarketInfo(market), nil
+}
+
+// Ticker is a market's latest prices
+func (e *Exchange) Ticker(ctx context.Context, symbol string) (exchange.Ticker, error) {
+	ticker, err := e.client.GetTicker(ctx, symbol)
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	return exchange.Ticker{Symbol: ticker.Symbol, Last: ticker.LastTradeRate, Bid: ticker.BidRate, Ask: ticker.AskRate}, nil
+}
+
+// Candles are a market's recent closed candles, oldest first
+func (e *Exchange) Candles(ctx context.Context, symbol string, interval string) ([]exchange.Candle, error) {
+	ret := make([]exchange.Candle, 0)
+	native, ok := CandleIntervals[interval]
+	if !ok {
+		return ret, fmt.Errorf("%w: bittrex has no %s candles", exchange.ErrUnsupported, interval)
+	}
+	candles, err := e.client.GetCandles(ctx, symbol, native)
+	if err != nil {
+		return ret, err
+	}
+	for _, candle := range candles {
+		ret = append(ret, ToCandle(candle))
+	}
+	return ret, nil
+}
+
+// PlaceOrder sends an order to bittrex
+func (e *Exchange) PlaceOrder(ctx context.Context, order exchange.OrderRequest) (exchange.Order, error) {
+	placed, err := e.client.Order(ctx, NewOrder{
+		MarketSymbol:  order.Symbol,
+		Direction:     order.Side,
+		Type:          order.Type,
+		Quantity:      order.Quantity,
+		Limit:         order.Limit,
+		TimeInForce:   order.TimeInForce,
+		ClientOrderID: order.ClientOrderID,
+	})
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(placed), nil
+}
+
+// GetOrder looks an order up by its bittrex id. Bittrex ids are unique across markets, so symbol isn't needed.
+func (e *Exchange) GetOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	order, err := e.client.GetOrder(ctx, id)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(order), nil
+}
+
+// FindOrder looks an order up by the id we gave it
+func (e *Exchange) FindOrder(ctx context.Context, symbol string, clientOrderID string) (exchange.Order, error) {
+	order, err := e.client.FindOrder(ctx, symbol, clientOrderID)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(order), nil
+}
+
+// CancelOrder stops an order from filling any more
+func (e *Exchange) CancelOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	order, err := e.client.CancelOrder(ctx, id)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(order), nil
+}
+
+// OpenOrders is every order on a market that can still fill
+func (e *Exchange) OpenOrders(ctx context.Context, symbol string) ([]exchange.Order, error) {
+	ret := make([]exchange.Order, 0)
+	orders, err := e.client.ListOpenOrders(ctx, symbol)
+	if err != nil {
+		return ret, err
+	}
+	for _, order := range orders {
+		ret = append(ret, toOrder(order))
+	}
+	return ret, nil
+}
+
+// ToCandle is a bittrex candle in the shape every venue shares
+func ToCandle(candle CandleResponse) exchange.Candle {
+	return exchange.Candle{
+		StartsAt: candle.StartsAt,
+		Open:     candle.Open,
+		High:     candle.High,
+		Low:      candle.Low,
+		Close:    candle.Close,
+		Volume:   candle.Volume,
+	}
+}
+
+func toMarketInfo(market MarketInfoResponse) exchange.MarketInfo {
+	return exchange.MarketInfo{
+		Symbol:      market.Symbol,
+		Native:      market.Symbol,
+		Base:        market.BaseCurrencySymbol,
+		Quote:       market.QuoteCurrencySymbol,
+		MinQuantity: market.MinTradeSize,
+		StepSize:    decimal.New(1, -QuantityPrecision),
+		TickSize:    decimal.New(1, -market.Precision),
+		Active:      market.Status == "ONLINE",
+	}
+}
+
+func toOrder(order OrderResponse) exchange.Order {
+	return exchange.Order{
+		ID:             order.ID,
+		ClientOrderID:  order.ClientOrderID,
+		Symbol:         order.MarketSymbol,
+		Side:           order.Direction,
+		Type:           order.Type,
+		TimeInForce:    order.TimeInForce,
+		Quantity:       order.Quantity,
+		Limit:          order.Limit,
+		FilledQuantity: order.FillQuantity,
+		Proceeds:       order.Proceeds,
+		Commission:     order.Commission,
+		Status:         order.Status,
+		CreatedAt:      order.CreatedAt,
+		UpdatedAt:      order.UpdatedAt,
+		ClosedAt:       order.ClosedAt,
+	}
+}
+
+func toOrderRequest(order NewOrder) exchange.OrderRequest {
+	return exchange.OrderRequest{
+		Symbol:        order.MarketSymbol,
+		Side:          order.Direction,
+		Type:          order.Type,
+		Quantity:      order.Quantity,
+		Limit:         order.Limit,
+		TimeInForce:   order.TimeInForce,
+		ClientOrderID: order.ClientOrderID,
+	}
+}
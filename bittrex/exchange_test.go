@@ -0,0 +1,108 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestVenue(t *testing.T) *Exchange {
+	server := httptest.NewServer(newTestExchange(t).Handler())
+	t.Cleanup(server.Close)
+	return NewExchange(NewClient(WithBaseURL(server.URL), WithRateLimiter(nil)))
+}
+
+func TestExchangeMarketData(t *testing.T) {
+	venue := newTestVenue(t)
+	ctx := context.Background()
+
+	market, err := venue.Market(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if market.Base != "DOGE" || market.Quote != "USD" || !market.Active {
+		t.Errorf("Market was %+v", market)
+	}
+	if market.TickSize.String() != "0.00001" || market.StepSize.String() != "0.00000001" || market.MinQuantity.String() != "50" {
+		t.Errorf("Market rules were tick %s, step %s, min %s", market.TickSize, market.StepSize, market.MinQuantity)
+	}
+
+	candles, err := venue.Candles(ctx, "DOGE-USD", exchange.Interval1Min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) == 0 {
+		t.Fatal("Expected some candles")
+	}
+	for i := 1; i < len(candles); i++ {
+		if !candles[i].StartsAt.After(candles[i-1].StartsAt) {
+			t.Fatalf("Candles out of order at %d", i)
+		}
+	}
+
+	_, err = venue.Candles(ctx, "DOGE-USD", "3min")
+	if !errors.Is(err, exchange.ErrUnsupported) {
+		t.Errorf("Expected an unknown interval to be unsupported, got %v", err)
+	}
+}
+
+func TestExchangeOrders(t *testing.T) {
+	venue := newTestVenue(t)
+	ctx := context.Background()
+
+	order, err := venue.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:        "DOGE-USD",
+		Side:          exchange.Buy,
+		Type:          exchange.Limit,
+		Quantity:      decimal.NewFromInt(100),
+		Limit:         decimal.RequireFromString("0.04"),
+		TimeInForce:   exchange.GoodTilCancelled,
+		ClientOrderID: "a2b5b0a6-86a1-4f5e-9b46-5c1d0e8b7f10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != exchange.Open || !order.FilledQuantity.IsZero() {
+		t.Fatalf("Expected a resting order, got %+v", order)
+	}
+
+	found, err := venue.FindOrder(ctx, "DOGE-USD", "a2b5b0a6-86a1-4f5e-9b46-5c1d0e8b7f10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != order.ID {
+		t.Errorf("Found order %s, expected %s", found.ID, order.ID)
+	}
+	open, err := venue.OpenOrders(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 {
+		t.Errorf("Got %d open orders, expected 1", len(open))
+	}
+
+	canceled, err := venue.CancelOrder(ctx, "DOGE-USD", order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Status != exchange.Closed {
+		t.Errorf("Canceled order was %s", canceled.Status)
+	}
+
+	_, err = venue.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:      "DOGE-USD",
+		Side:        exchange.Buy,
+		Type:        exchange.Limit,
+		Quantity:    decimal.NewFromInt(1000000),
+		Limit:       decimal.RequireFromString("0.04"),
+		TimeInForce: exchange.GoodTilCancelled,
+	})
+	if !errors.Is(err, exchange.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds, got %v", err)
+	}
+}
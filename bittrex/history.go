@@ -0,0 +1,64 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// historicalBucketPath is the end of a historical candles url for the bucket an interval is kept in
+func historicalBucketPath(interval string, year int, month int, day int) (string, error) {
+	switch interval {
+	case CandleIntervals["1min"], CandleIntervals["5min"]:
+		return fmt.Sprintf("%d/%d/%d", year, month, day), nil
+	case CandleIntervals["1hour"]:
+		return fmt.Sprintf("%d/%d", year, month), nil
+	case CandleIntervals["1day"]:
+		return fmt.Sprintf("%d", year), nil
+	}
+	return "", fmt.Errorf("unknown candle interval %q", interval)
+}
+
+// HistoricalBucket is the start of the historical bucket t falls in, and the start of the one after it
+func HistoricalBucket(interval string, t time.Time) (time.Time, time.Time, error) {
+	t = t.UTC()
+	switch interval {
+	case CandleIntervals["1min"], CandleIntervals["5min"]:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1), nil
+	case CandleIntervals["1hour"]:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), nil
+	case CandleIntervals["1day"]:
+		start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown candle interval %q", interval)
+}
+
+// GetCandlesRange gets every candle starting in [from, to), stitched together from as many historical
+// buckets as it takes
+func (c *Client) GetCandlesRange(ctx context.Context, symbol string, interval string, from time.Time, to time.Time) ([]CandleResponse, error) {
+	ret := make([]CandleResponse, 0)
+	bucket, _, err := HistoricalBucket(interval, from)
+	if err != nil {
+		return ret, err
+	}
+	for bucket.Before(to) {
+		candles, err := c.GetHistoricalCandles(ctx, symbol, interval, bucket.Year(), int(bucket.Month()), bucket.Day())
+		if err != nil {
+			return make([]CandleResponse, 0), err
+		}
+		for _, candle := range candles {
+			if !candle.StartsAt.Before(from) && candle.StartsAt.Before(to) {
+				ret = append(ret, candle)
+			}
+		}
+		_, bucket, _ = HistoricalBucket(interval, bucket)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].StartsAt.Before(ret[j].StartsAt)
+	})
+	return ret, nil
+}
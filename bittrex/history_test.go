@@ -0,0 +1,118 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// historicalServer answers every historical bucket with one candle at the start of the bucket and one at the
+// middle of it, remembering which paths were asked for
+func historicalServer(t *testing.T, interval string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		var year, month, day int
+		month, day = 1, 1
+		prefix := "/v3/markets/DOGE-USD/candles/" + interval + "/historical/"
+		rest := r.URL.Path[len(prefix):]
+		switch interval {
+		case CandleIntervals["1day"]:
+			parse(t, rest, "%d", &year)
+		case CandleIntervals["1hour"]:
+			parse(t, rest, "%d/%d", &year, &month)
+		default:
+			parse(t, rest, "%d/%d/%d", &year, &month, &day)
+		}
+		start, end, _ := HistoricalBucket(interval, time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC))
+		middle := start.Add(end.Sub(start) / 2)
+		json.NewEncoder(w).Encode([]CandleResponse{{StartsAt: middle}, {StartsAt: start}})
+	}))
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, paths...)
+	}
+}
+
+func parse(t *testing.T, s string, format string, parts ...interface{}) {
+	if _, err := fmt.Sscanf(s, format, parts...); err != nil {
+		t.Fatalf("Could not parse %q: %s", s, err)
+	}
+}
+
+func TestHistoricalCandlesUseBaseURL(t *testing.T) {
+	tests := map[string]string{
+		CandleIntervals["1min"]:  "/v3/markets/DOGE-USD/candles/MINUTE_1/historical/2020/6/3",
+		CandleIntervals["5min"]:  "/v3/markets/DOGE-USD/candles/MINUTE_5/historical/2020/6/3",
+		CandleIntervals["1hour"]: "/v3/markets/DOGE-USD/candles/HOUR_1/historical/2020/6",
+		CandleIntervals["1day"]:  "/v3/markets/DOGE-USD/candles/DAY_1/historical/2020",
+	}
+	for interval, expected := range tests {
+		server, paths := historicalServer(t, interval)
+		client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+		_, err := client.GetHistoricalCandles(context.Background(), "DOGE-USD", interval, 2020, 6, 3)
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := paths()
+		if len(got) != 1 || got[0] != expected {
+			t.Errorf("%s asked for %v, expected %s", interval, got, expected)
+		}
+	}
+
+	client := NewClient(WithRateLimiter(nil))
+	_, err := client.GetHistoricalCandles(context.Background(), "DOGE-USD", "MINUTE_15", 2020, 6, 3)
+	if err == nil {
+		t.Error("Expected an unknown interval to fail before sending anything")
+	}
+}
+
+func TestGetCandlesRangeStitchesBuckets(t *testing.T) {
+	tests := []struct {
+		interval string
+		from     time.Time
+		to       time.Time
+		requests int
+		candles  int
+	}{
+		// Days 1 through 3, dropping the start of day 1 and the middle of day 3
+		{CandleIntervals["1min"], time.Date(2020, 6, 1, 6, 0, 0, 0, time.UTC), time.Date(2020, 6, 3, 6, 0, 0, 0, time.UTC), 3, 4},
+		// Across a year boundary by month
+		{CandleIntervals["1hour"], time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), 3, 6},
+		{CandleIntervals["1day"], time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), 2, 4},
+	}
+	for _, test := range tests {
+		server, paths := historicalServer(t, test.interval)
+		client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+		candles, err := client.GetCandlesRange(context.Background(), "DOGE-USD", test.interval, test.from, test.to)
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths()) != test.requests {
+			t.Errorf("%s made %d requests, expected %d", test.interval, len(paths()), test.requests)
+		}
+		if len(candles) != test.candles {
+			t.Fatalf("%s got %d candles, expected %d", test.interval, len(candles), test.candles)
+		}
+		for i, candle := range candles {
+			if candle.StartsAt.Before(test.from) || !candle.StartsAt.Before(test.to) {
+				t.Errorf("%s candle at %s is outside the range", test.interval, candle.StartsAt)
+			}
+			if i > 0 && !candles[i-1].StartsAt.Before(candle.StartsAt) {
+				t.Errorf("%s candles are out of order at %d", test.interval, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package bittrex
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// retryableStatuses are responses worth asking again for, anything else will fail the same way twice
+	retryableStatuses = map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+	}
+)
+
+// RetryPolicy decides how GETs are retried. Orders are never retried.
+type RetryPolicy struct {
+	// MaxAttempts includes the first try, 1 turns retries off
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, it doubles every attempt after that
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff, but not a Retry-After from bittrex
+	MaxDelay time.Duration
+}
+
+// backoff is a jittered exponential delay before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << uint(retry-1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryAfter reads a Retry-After header, in either seconds or a date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if date.Before(now) {
+			return 0, true
+		}
+		return date.Sub(now), true
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+package bittrex
+
+import (
+	"context"
+	"cryptofu/ratelimit"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond * 5}
+
+func TestGetRetriesTransientFailures(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"symbol":"DOGE-USD"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries))
+	ticker, err := client.GetTicker(context.Background(), "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Symbol != "DOGE-USD" || calls != 3 {
+		t.Errorf("Got %s after %d calls", ticker.Symbol, calls)
+	}
+}
+
+func TestGetGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries)).PokeAPI(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Was %v, expected the last 500", err)
+	}
+	if calls != 3 {
+		t.Errorf("Called %d times, expected 3", calls)
+	}
+}
+
+func TestGetHonorsRetryAfter(t *testing.T) {
+	var first time.Time
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if time.Since(first) < time.Second {
+			t.Error("Retried before Retry-After was up")
+		}
+	}))
+	defer server.Close()
+
+	err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries)).PokeAPI(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries)).PokeAPI(context.Background())
+	if !errors.Is(err, ErrNotFound) || calls != 1 {
+		t.Errorf("Was %v after %d calls", err, calls)
+	}
+}
+
+func TestOrderIsNeverRetried(t *testing.T) {
+	calls := 0
+	var clientOrderID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var order NewOrder
+		decodeBody(t, r, &order)
+		clientOrderID = order.ClientOrderID
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetries)).Order(context.Background(), NewOrder{MarketSymbol: "DOGE-USD"})
+	if err == nil || calls != 1 {
+		t.Errorf("Was %v after %d calls", err, calls)
+	}
+	if len(clientOrderID) != 36 {
+		t.Errorf("Client order id was %q, expected a uuid", clientOrderID)
+	}
+}
+
+func TestRateLimiterIsShared(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	limiter := ratelimit.New(20, 1)
+	mochi := NewClient(WithBaseURL(server.URL), WithRateLimiter(limiter))
+	bao := NewClient(WithBaseURL(server.URL), WithRateLimiter(limiter))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := mochi.PokeAPI(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := bao.PokeAPI(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One call is free, the other five wait 50ms each
+	if elapsed := time.Since(start); elapsed < time.Millisecond*240 {
+		t.Errorf("Six calls took %s, expected the shared limit to slow them down", elapsed)
+	}
+}
@@ -0,0 +1,101 @@
+package bittrex
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// OrderBookDepths are the only depths bittrex will send an order book at
+	OrderBookDepths = []int{1, 25, 500}
+)
+
+// GetOrderBook gets the bids and asks for a symbol. depth has to be one of OrderBookDepths.
+func (c *Client) GetOrderBook(ctx context.Context, symbol string, depth int) (OrderBookResponse, error) {
+	if !validDepth(depth) {
+		return OrderBookResponse{}, fmt.Errorf("order book depth %d has to be one of %v", depth, OrderBookDepths)
+	}
+	url := fmt.Sprintf("%s/%s/markets/%s/orderbook?depth=%d", c.baseURL, APIVersion, symbol, depth)
+	resp, err := c.get(ctx, url, false)
+	if err != nil {
+		return OrderBookResponse{}, err
+	}
+
+	sequence := resp.Header.Get("Sequence")
+
+	var ret OrderBookResponse
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return OrderBookResponse{}, err
+	}
+
+	if sequence != "" {
+		ret.Sequence, err = strconv.ParseInt(sequence, 10, 64)
+		if err != nil {
+			return OrderBookResponse{}, err
+		}
+	}
+
+	return ret, nil
+}
+
+// GetRecentTrades gets the most recent trades on a symbol
+func (c *Client) GetRecentTrades(ctx context.Context, symbol string) ([]TradeResponse, error) {
+	url := fmt.Sprintf("%s/%s/markets/%s/trades", c.baseURL, APIVersion, symbol)
+	resp, err := c.get(ctx, url, false)
+	if err != nil {
+		return []TradeResponse{}, err
+	}
+
+	ret := make([]TradeResponse, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []TradeResponse{}, err
+	}
+
+	return ret, nil
+}
+
+func validDepth(depth int) bool {
+	for _, allowed := range OrderBookDepths {
+		if depth == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMarkets gets the precision, minimum trade size and status of every market
+func (c *Client) GetMarkets(ctx context.Context) ([]MarketInfoResponse, error) {
+	url := fmt.Sprintf("%s/%s/markets", c.baseURL, APIVersion)
+	resp, err := c.get(ctx, url, false)
+	if err != nil {
+		return []MarketInfoResponse{}, err
+	}
+
+	ret := make([]MarketInfoResponse, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []MarketInfoResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// GetMarketInfo gets the precision, minimum trade size and status of one market
+func (c *Client) GetMarketInfo(ctx context.Context, symbol string) (MarketInfoResponse, error) {
+	url := fmt.Sprintf("%s/%s/markets/%s", c.baseURL, APIVersion, symbol)
+	resp, err := c.get(ctx, url, false)
+	if err != nil {
+		return MarketInfoResponse{}, err
+	}
+
+	var ret MarketInfoResponse
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return MarketInfoResponse{}, err
+	}
+
+	return ret, nil
+}
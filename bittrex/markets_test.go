@@ -0,0 +1,54 @@
+package bittrex
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetOrderBookFromMockServer(t *testing.T) {
+	server := httptest.NewServer(newTestExchange(t).Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+
+	book, err := client.GetOrderBook(context.Background(), "DOGE-USD", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Bid) != 25 || len(book.Ask) != 25 || book.Sequence != 1 {
+		t.Fatalf("Got %d bids, %d asks at sequence %d", len(book.Bid), len(book.Ask), book.Sequence)
+	}
+	// Both sides start at the 0.05 close and step out a tick of DOGE-USD's precision at a time
+	if book.Bid[0].Rate.String() != "0.05" || book.Ask[0].Rate.String() != "0.05" || book.Ask[1].Rate.String() != "0.05001" {
+		t.Errorf("Best bid was %s and best asks were %s and %s", book.Bid[0].Rate, book.Ask[0].Rate, book.Ask[1].Rate)
+	}
+	if !book.Spread().IsZero() {
+		t.Errorf("Spread was %s, expected none", book.Spread())
+	}
+	if book.BidDepth().String() != "28000" {
+		t.Errorf("Bid depth was %s, expected 28000", book.BidDepth())
+	}
+
+	_, err = client.GetOrderBook(context.Background(), "DOGE-USD", 10)
+	if err == nil {
+		t.Error("Expected a depth bittrex doesn't support to fail")
+	}
+}
+
+func TestGetRecentTradesFromMockServer(t *testing.T) {
+	server := httptest.NewServer(newTestExchange(t).Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+
+	trades, err := client.GetRecentTrades(context.Background(), "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 10 {
+		t.Fatalf("Got %d trades, expected 10", len(trades))
+	}
+	last := trades[len(trades)-1]
+	if last.Quantity.String() != "1000" || last.Rate.String() != "0.05009" || last.TakerSide != "SELL" {
+		t.Errorf("Last trade was %+v", last)
+	}
+}
@@ -0,0 +1,309 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrScenarioFinished is what a MockExchange answers with once its clock passes the scenario's end
+	ErrScenarioFinished = errors.New("scenario finished")
+)
+
+// MockExchange is a local stand-in for bittrex that replays a Scenario. It answers every endpoint the client
+// uses from the scenario's candles as of its simulated clock, so nothing after the clock ever leaks out.
+type MockExchange struct {
+	scenario Scenario
+	stream   *MockStreamServer
+	markets  []MarketInfoResponse
+	server   *http.Server
+	url      string
+	faults   *mockFaults
+
+	mu         sync.Mutex
+	now        time.Time
+	candles    map[string][]CandleResponse
+	orders     []OrderResponse
+	executions []ExecutionResponse
+	balances   map[string]decimal.Decimal
+	finished   chan struct{}
+}
+
+// NewMockExchange loads a scenario's candles and sets the clock to its start
+func NewMockExchange(scenario Scenario) (*MockExchange, error) {
+	err := scenario.validate()
+	if err != nil {
+		return nil, err
+	}
+	candles, err := loadScenarioCandles(scenario)
+	if err != nil {
+		return nil, err
+	}
+	balances := map[string]decimal.Decimal{}
+	for currency, total := range scenario.Balances {
+		balances[currency] = total
+	}
+	markets := make([]MarketInfoResponse, 0, len(scenario.Symbols))
+	for _, symbol := range scenario.Symbols {
+		markets = append(markets, mockMarketInfo(symbol))
+	}
+	return &MockExchange{
+		scenario:   scenario,
+		stream:     NewMockStreamServer(),
+		markets:    markets,
+		faults:     newMockFaults(scenario.Faults),
+		now:        scenario.Start,
+		candles:    candles,
+		orders:     []OrderResponse{},
+		executions: []ExecutionResponse{},
+		balances:   balances,
+		finished:   make(chan struct{}),
+	}, nil
+}
+
+// Now is the simulated time
+func (e *MockExchange) Now() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.now
+}
+
+// Advance moves the simulated clock forward. Resting orders fill against every candle that completes on the
+// way, and the candles, tickers and order changes are pushed to stream subscribers.
+func (e *MockExchange) Advance(d time.Duration) {
+	e.mu.Lock()
+	from := e.now
+	e.now = e.now.Add(d)
+	to := e.now
+	if !to.Before(e.scenario.End) && !e.isFinished() {
+		close(e.finished)
+	}
+	e.mu.Unlock()
+
+	for _, symbol := range e.scenario.Symbols {
+		e.publishChanges(e.matchResting(symbol, e.completedBetween(symbol, e.finestInterval(), from, to)))
+		for _, interval := range e.scenario.Intervals {
+			for _, candle := range e.completedBetween(symbol, interval, from, to) {
+				e.stream.PublishCandle(symbol, interval, candle)
+			}
+		}
+		if ticker, ok := e.ticker(symbol); ok {
+			e.stream.PublishTicker(ticker)
+		}
+	}
+}
+
+// Done is closed once the clock reaches the end of the scenario
+func (e *MockExchange) Done() <-chan struct{} {
+	return e.finished
+}
+
+// Stream is the websocket stand-in the exchange serves under /signalr
+func (e *MockExchange) Stream() *MockStreamServer {
+	return e.stream
+}
+
+func (e *MockExchange) isFinished() bool {
+	select {
+	case <-e.finished:
+		return true
+	default:
+		return false
+	}
+}
+
+// completed is every candle of a series that has closed by the simulated time
+func (e *MockExchange) completed(symbol string, interval string) []CandleResponse {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.completedBetween(symbol, interval, time.Time{}, e.now)
+}
+
+// completedBetween is every candle that closes in (from, to]. The loaded candles never change, so it doesn't
+// need the lock.
+func (e *MockExchange) completedBetween(symbol string, interval string, from time.Time, to time.Time) []CandleResponse {
+	length := candleIntervalDurations[interval]
+	ret := make([]CandleResponse, 0)
+	for _, candle := range e.candles[scenarioKey(symbol, interval)] {
+		closesAt := candle.StartsAt.Add(length)
+		if closesAt.After(from) && !closesAt.After(to) {
+			ret = append(ret, candle)
+		}
+	}
+	return ret
+}
+
+// finestInterval is the scenario's shortest candle interval
+func (e *MockExchange) finestInterval() string {
+	finest := e.scenario.Intervals[0]
+	for _, interval := range e.scenario.Intervals {
+		if candleIntervalDurations[interval] < candleIntervalDurations[finest] {
+			finest = interval
+		}
+	}
+	return finest
+}
+
+// lastCandle is the latest closed candle of a symbol at the finest interval the scenario has
+func (e *MockExchange) lastCandle(symbol string) (CandleResponse, bool) {
+	candles := e.completed(symbol, e.finestInterval())
+	if len(candles) == 0 {
+		return CandleResponse{}, false
+	}
+	return candles[len(candles)-1], true
+}
+
+// midRate is where a symbol's order book and trades sit right now
+func (e *MockExchange) midRate(symbol string) (decimal.Decimal, bool) {
+	candle, ok := e.lastCandle(symbol)
+	if !ok {
+		return decimal.Zero, false
+	}
+	return candle.Close, true
+}
+
+func (e *MockExchange) ticker(symbol string) (TickerResponse, bool) {
+	mid, ok := e.midRate(symbol)
+	if !ok {
+		return TickerResponse{}, false
+	}
+	// The top of the mock order book
+	return TickerResponse{Symbol: symbol, LastTradeRate: mid, BidRate: mid, AskRate: mid}, true
+}
+
+func (e *MockExchange) hasSymbol(symbol string) bool {
+	for _, known := range e.scenario.Symbols {
+		if known == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *MockExchange) getCandles(w http.ResponseWriter, r *http.Request) {
+	symbol, interval := mux.Vars(r)["symbol"], mux.Vars(r)["interval"]
+	if !e.hasSymbol(symbol) {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	if _, ok := e.candles[scenarioKey(symbol, interval)]; !ok {
+		writeMockError(w, http.StatusBadRequest, "INVALID_CANDLE_INTERVAL")
+		return
+	}
+	if e.isFinished() {
+		writeMockError(w, http.StatusGone, "SCENARIO_FINISHED")
+		return
+	}
+
+	// Whoever polls the lead series sets the pace of the replay. The clock moves before answering, so the last
+	// candle in the answer is the one the order book and ticker are built around until the next poll.
+	if symbol == e.scenario.Symbols[0] && interval == e.scenario.Intervals[0] {
+		e.Advance(e.scenario.Tick)
+	}
+
+	asOf := e.candlesAsOf(r)
+	window := asOf.Add(-RecentCandlesWindow(interval))
+	response := make([]CandleResponse, 0)
+	for _, candle := range e.completedBetween(symbol, interval, time.Time{}, asOf) {
+		if !candle.StartsAt.Before(window) {
+			response = append(response, candle)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// getHistoricalCandles only serves buckets that have finished by the simulated time
+func (e *MockExchange) getHistoricalCandles(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	symbol, interval := vars["symbol"], vars["interval"]
+	if !e.hasSymbol(symbol) {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	if _, ok := e.candles[scenarioKey(symbol, interval)]; !ok {
+		writeMockError(w, http.StatusBadRequest, "INVALID_CANDLE_INTERVAL")
+		return
+	}
+	parts := []int{0, 1, 1}
+	for i, part := range strings.Split(vars["bucket"], "/") {
+		parsed, err := strconv.Atoi(part)
+		if err != nil || i >= len(parts) {
+			writeMockError(w, http.StatusBadRequest, "BAD_REQUEST")
+			return
+		}
+		parts[i] = parsed
+	}
+	start, end, _ := HistoricalBucket(interval, time.Date(parts[0], time.Month(parts[1]), parts[2], 0, 0, 0, 0, time.UTC))
+	if end.After(e.Now()) {
+		writeMockError(w, http.StatusNotFound, "NOT_FOUND")
+		return
+	}
+	response := make([]CandleResponse, 0)
+	for _, candle := range e.candles[scenarioKey(symbol, interval)] {
+		if !candle.StartsAt.Before(start) && candle.StartsAt.Before(end) {
+			response = append(response, candle)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (e *MockExchange) getTicker(w http.ResponseWriter, r *http.Request) {
+	ticker, ok := e.ticker(mux.Vars(r)["symbol"])
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticker)
+}
+
+// getSummary sums up the last day of the finest candles the scenario has
+func (e *MockExchange) getSummary(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+	last, ok := e.lastCandle(symbol)
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	now := e.Now()
+	summary := MarketResponse{Symbol: symbol, High: last.High, Low: last.Low, Volume: decimal.Zero, QuoteVolume: decimal.Zero, UpdatedAt: now}
+	open := decimal.Zero
+	for _, candle := range e.completed(symbol, e.finestInterval()) {
+		if candle.StartsAt.Before(now.Add(-time.Hour * 24)) {
+			continue
+		}
+		if open.IsZero() {
+			open = candle.Open
+		}
+		summary.High = decimal.Max(summary.High, candle.High)
+		summary.Low = decimal.Min(summary.Low, candle.Low)
+		summary.Volume = summary.Volume.Add(candle.Volume)
+		summary.QuoteVolume = summary.QuoteVolume.Add(candle.QuoteVolume)
+	}
+	if !open.IsZero() {
+		summary.PercentChange = last.Close.Sub(open).Div(open).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (e *MockExchange) getBalances(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	response := make(BalancesResponce, 0, len(e.balances))
+	for currency := range e.balances {
+		response = append(response, e.balance(currency))
+	}
+	e.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
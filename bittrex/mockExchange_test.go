@@ -0,0 +1,226 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// testScenarioStart is a day after the test candles start, so there is a day of history to serve
+var testScenarioStart = time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)
+
+// writeTestCandles saves two days of minute candles for symbol under dir, closing at close every minute
+func writeTestCandles(t *testing.T, dir string, symbol string, close string) {
+	folder := filepath.Join(dir, symbol, CandleIntervals["1min"])
+	err := os.MkdirAll(folder, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for day := 1; day <= 2; day++ {
+		file, err := os.Create(filepath.Join(folder, fmt.Sprintf("2020-06-0%d.jsonl", day)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoder := json.NewEncoder(file)
+		start := time.Date(2020, 6, day, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 24*60; i++ {
+			rate := decimal.RequireFromString(close)
+			encoder.Encode(CandleResponse{StartsAt: start.Add(time.Duration(i) * time.Minute), Open: rate, High: rate, Low: rate, Close: rate, Volume: decimal.NewFromInt(10), QuoteVolume: rate.Mul(decimal.NewFromInt(10))})
+		}
+		file.Close()
+	}
+}
+
+func testScenario(t *testing.T) Scenario {
+	dir := t.TempDir()
+	writeTestCandles(t, dir, "DOGE-USD", "0.05")
+	writeTestCandles(t, dir, "BTC-USD", "9000")
+	return Scenario{
+		Symbols:   []string{"DOGE-USD", "BTC-USD"},
+		Intervals: []string{CandleIntervals["1min"]},
+		Data:      dir,
+		Start:     testScenarioStart,
+		End:       testScenarioStart.Add(time.Hour * 12),
+		Tick:      time.Minute,
+		Addr:      "127.0.0.1:0",
+		Balances:  map[string]decimal.Decimal{"USD": decimal.NewFromInt(1000)},
+	}
+}
+
+func newTestExchange(t *testing.T) *MockExchange {
+	exchange, err := NewMockExchange(testScenario(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exchange
+}
+
+func TestMockExchangeReplaysScenario(t *testing.T) {
+	exchange := newTestExchange(t)
+	err := exchange.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exchange.Close()
+	client := NewClient(WithBaseURL(exchange.URL()), WithRateLimiter(nil))
+	ctx := context.Background()
+
+	candles, err := client.GetCandles(ctx, "DOGE-USD", CandleIntervals["1min"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The clock moves a tick before answering, so the first poll already has the first candle of the scenario
+	if len(candles) != 24*60 || !candles[len(candles)-1].StartsAt.Equal(testScenarioStart) {
+		t.Fatalf("Got %d candles ending at %s", len(candles), candles[len(candles)-1].StartsAt)
+	}
+	if !exchange.Now().Equal(testScenarioStart.Add(time.Minute)) {
+		t.Errorf("Clock is at %s, expected one tick past the start", exchange.Now())
+	}
+	// Only the lead series moves the clock
+	_, err = client.GetCandles(ctx, "BTC-USD", CandleIntervals["1min"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	candles, err = client.GetCandles(ctx, "DOGE-USD", CandleIntervals["1min"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !candles[len(candles)-1].StartsAt.Equal(testScenarioStart.Add(time.Minute)) {
+		t.Errorf("Second poll ended at %s, expected the next new candle", candles[len(candles)-1].StartsAt)
+	}
+
+	ticker, err := client.GetTicker(ctx, "BTC-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.LastTradeRate.String() != "9000" || ticker.BidRate.String() != "9000" {
+		t.Errorf("Ticker was %+v", ticker)
+	}
+	summary, err := client.GetMarket(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Volume.String() != "14400" || !summary.PercentChange.IsZero() {
+		t.Errorf("Summary was %+v", summary)
+	}
+	balances, err := client.GetBalances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 1 || balances[0].CurrencySymbol != "USD" || balances[0].Total.String() != "1000" {
+		t.Errorf("Balances were %+v", balances)
+	}
+	markets, err := client.GetMarkets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 2 || markets[1].MinTradeSize.String() != "0.0001" {
+		t.Errorf("Markets were %+v", markets)
+	}
+
+	// The day the scenario is in the middle of isn't history yet
+	_, err = client.GetHistoricalCandles(ctx, "DOGE-USD", CandleIntervals["1min"], 2020, 6, 2)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the current day to be not found, got %v", err)
+	}
+	history, err := client.GetHistoricalCandles(ctx, "DOGE-USD", CandleIntervals["1min"], 2020, 6, 1)
+	if err != nil || len(history) != 24*60 {
+		t.Errorf("Got %d historical candles, %v", len(history), err)
+	}
+}
+
+func TestMockExchangesRunSideBySide(t *testing.T) {
+	urls := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		scenario := testScenario(t)
+		scenario.Addr = DefaultScenario().Addr
+		exchange, err := NewMockExchange(scenario)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = exchange.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer exchange.Close()
+		_, err = NewClient(WithBaseURL(exchange.URL()), WithRateLimiter(nil)).GetTicker(context.Background(), "DOGE-USD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[exchange.URL()] = true
+	}
+	if len(urls) != 2 {
+		t.Errorf("Both exchanges ended up at %v", urls)
+	}
+}
+
+func TestMockExchangeFinishes(t *testing.T) {
+	exchange := newTestExchange(t)
+	server := httptest.NewServer(exchange.Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+
+	exchange.Advance(time.Hour * 12)
+	select {
+	case <-exchange.Done():
+	default:
+		t.Fatal("Exchange isn't done at the end of the scenario")
+	}
+	_, err := client.GetCandles(context.Background(), "DOGE-USD", CandleIntervals["1min"])
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusGone || apiErr.Code != "SCENARIO_FINISHED" {
+		t.Errorf("Expected the scenario to be finished, got %v", err)
+	}
+}
+
+func TestLoadScenarioFromFile(t *testing.T) {
+	dir := t.TempDir()
+	csv := "startsAt,open,high,low,close,volume,quoteVolume\n" +
+		"2020-06-01T00:00:00Z,0.05,0.06,0.04,0.055,100,5.5\n" +
+		"2020-06-01T01:00:00Z,0.055,0.06,0.05,0.06,100,6\n"
+	err := ioutil.WriteFile(filepath.Join(dir, "eth.csv"), []byte(csv), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := `{"Symbols": ["ETH-USD"], "Intervals": ["HOUR_1"], "Data": "eth.csv", "Start": "2020-06-01T02:00:00Z", "End": "2020-06-02T00:00:00Z", "Tick": "1h"}`
+	err = ioutil.WriteFile(filepath.Join(dir, "scenario.json"), []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenario, err := LoadScenario(filepath.Join(dir, "scenario.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scenario.Tick != time.Hour || scenario.Addr != DefaultScenario().Addr || scenario.Data != filepath.Join(dir, "eth.csv") {
+		t.Errorf("Scenario was %+v", scenario)
+	}
+	exchange, err := NewMockExchange(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticker, ok := exchange.ticker("ETH-USD")
+	if !ok || ticker.LastTradeRate.String() != "0.06" {
+		t.Errorf("Ticker was %+v", ticker)
+	}
+	market, _ := exchange.findMarket("ETH-USD")
+	if market.BaseCurrencySymbol != "ETH" || market.QuoteCurrencySymbol != "USD" {
+		t.Errorf("Made up market was %+v", market)
+	}
+
+	scenario.Intervals = []string{"HOUR_1", "DAY_1"}
+	_, err = NewMockExchange(scenario)
+	if err == nil {
+		t.Error("Expected one file to be refused for two intervals")
+	}
+}
@@ -0,0 +1,212 @@
+package bittrex
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// FaultThrottle answers 429 THROTTLED, with a Retry-After of Duration if it is set
+	FaultThrottle = "throttle"
+	// FaultServerError answers 500
+	FaultServerError = "server_error"
+	// FaultUnavailable answers 503
+	FaultUnavailable = "unavailable"
+	// FaultLatency waits Duration before answering
+	FaultLatency = "latency"
+	// FaultDrop hangs up without answering
+	FaultDrop = "drop"
+	// FaultMalformed cuts the answer off halfway through its json
+	FaultMalformed = "malformed"
+	// FaultStaleCandles serves recent candles as they were Duration ago
+	FaultStaleCandles = "stale_candles"
+	// FaultClockSkew skews the server clock by Duration, so signed requests fail with INVALID_TIMESTAMP
+	FaultClockSkew = "clock_skew"
+
+	// mockTimestampTolerance is how far a signed request's Api-Timestamp can be from the server clock
+	mockTimestampTolerance = time.Second * 5
+	// AnyEndpoint is the FaultProfile key whose faults apply to every endpoint
+	AnyEndpoint = "*"
+)
+
+var (
+	knownFaults = map[string]bool{
+		FaultThrottle:     true,
+		FaultServerError:  true,
+		FaultUnavailable:  true,
+		FaultLatency:      true,
+		FaultDrop:         true,
+		FaultMalformed:    true,
+		FaultStaleCandles: true,
+		FaultClockSkew:    true,
+	}
+)
+
+// Fault is one way an endpoint misbehaves, some of the time
+type Fault struct {
+	Kind string
+	// Probability is the chance from 0 to 1 that a request runs into the fault
+	Probability float64
+	// Duration is the latency, Retry-After, staleness or skew, depending on Kind
+	Duration time.Duration
+}
+
+// FaultProfile is how a MockExchange misbehaves. Endpoints are keyed by route like
+// "/v3/markets/{symbol}/candles/{interval}/recent", optionally with the method in front like "POST /v3/orders",
+// or by AnyEndpoint. Faults are rolled in order from the same seeded source, so the same requests in the same
+// order run into the same faults every time.
+type FaultProfile struct {
+	Seed      int64
+	Endpoints map[string][]Fault
+}
+
+// mockFaults rolls a profile's dice
+type mockFaults struct {
+	mu      sync.Mutex
+	profile FaultProfile
+	random  *rand.Rand
+}
+
+func newMockFaults(profile FaultProfile) *mockFaults {
+	return &mockFaults{profile: profile, random: rand.New(rand.NewSource(profile.Seed))}
+}
+
+// roll is every fault a request runs into
+func (f *mockFaults) roll(method string, route string) []Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hit := make([]Fault, 0)
+	for _, key := range []string{method + " " + route, route, AnyEndpoint} {
+		for _, fault := range f.profile.Endpoints[key] {
+			if f.random.Float64() < fault.Probability {
+				hit = append(hit, fault)
+			}
+		}
+	}
+	return hit
+}
+
+// SetFaults swaps the exchange's fault profile, starting its dice over from the profile's seed
+func (e *MockExchange) SetFaults(profile FaultProfile) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults = newMockFaults(profile)
+}
+
+type staleKey struct{}
+
+// candlesAsOf is when recent candles should be served from, allowing for a stale candles fault
+func (e *MockExchange) candlesAsOf(r *http.Request) time.Time {
+	stale, _ := r.Context().Value(staleKey{}).(time.Duration)
+	return e.Now().Add(-stale)
+}
+
+// injectFaults is router middleware that makes requests run into the fault profile
+func (e *MockExchange) injectFaults(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		faults := e.faults
+		e.mu.Unlock()
+		route, _ := mux.CurrentRoute(r).GetPathTemplate()
+
+		malformed := false
+		for _, fault := range faults.roll(r.Method, route) {
+			switch fault.Kind {
+			case FaultLatency:
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(fault.Duration):
+				}
+			case FaultThrottle:
+				if fault.Duration > 0 {
+					w.Header().Set("Retry-After", strconv.Itoa(int(fault.Duration.Seconds())))
+				}
+				writeMockError(w, http.StatusTooManyRequests, "THROTTLED")
+				return
+			case FaultServerError:
+				writeMockError(w, http.StatusInternalServerError, "INTERNAL_ERROR")
+				return
+			case FaultUnavailable:
+				writeMockError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+				return
+			case FaultDrop:
+				dropConnection(w)
+				return
+			case FaultClockSkew:
+				if !timestampInTolerance(r, time.Now().Add(fault.Duration)) {
+					writeMockError(w, http.StatusBadRequest, "INVALID_TIMESTAMP")
+					return
+				}
+			case FaultStaleCandles:
+				r = r.WithContext(context.WithValue(r.Context(), staleKey{}, fault.Duration))
+			case FaultMalformed:
+				malformed = true
+			}
+		}
+
+		if !malformed {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buffered := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buffered, r)
+		w.WriteHeader(buffered.status)
+		w.Write(buffered.body.Bytes()[:buffered.body.Len()/2])
+	})
+}
+
+// timestampInTolerance is whether a signed request's timestamp is close enough to now. Unsigned requests
+// don't have a timestamp to be wrong.
+func timestampInTolerance(r *http.Request, now time.Time) bool {
+	header := r.Header.Get("Api-Timestamp")
+	if header == "" {
+		return true
+	}
+	millis, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := now.Sub(time.Unix(0, millis*int64(time.Millisecond)))
+	return skew <= mockTimestampTolerance && skew >= -mockTimestampTolerance
+}
+
+// dropConnection hangs up mid request, like a load balancer giving up
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+// bufferedResponse holds on to an answer so it can be mangled before it is sent
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(content []byte) (int, error) {
+	return b.body.Write(content)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
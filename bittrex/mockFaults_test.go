@@ -0,0 +1,157 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	pingRoute   = "/v3/ping"
+	tickerRoute = "/v3/markets/{symbol}/ticker"
+)
+
+func newFaultyExchange(t *testing.T, profile FaultProfile) (*httptest.Server, *Client) {
+	exchange := newTestExchange(t)
+	exchange.SetFaults(profile)
+	server := httptest.NewServer(exchange.Handler())
+	t.Cleanup(server.Close)
+	return server, NewClient(WithBaseURL(server.URL), WithRateLimiter(nil), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+}
+
+// pingOutcomes is the status of n raw pings, so retries don't hide anything
+func pingOutcomes(t *testing.T, server *httptest.Server, n int) []int {
+	ret := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		resp, err := http.Get(server.URL + pingRoute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		ret = append(ret, resp.StatusCode)
+	}
+	return ret
+}
+
+func TestFaultsAreReproducible(t *testing.T) {
+	profile := FaultProfile{Seed: 42, Endpoints: map[string][]Fault{
+		pingRoute: {{Kind: FaultUnavailable, Probability: 0.5}},
+	}}
+	first, _ := newFaultyExchange(t, profile)
+	second, _ := newFaultyExchange(t, profile)
+
+	a, b := pingOutcomes(t, first, 20), pingOutcomes(t, second, 20)
+	failures := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Run %d went %d one time and %d the other", i, a[i], b[i])
+		}
+		if a[i] == http.StatusServiceUnavailable {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(a) {
+		t.Errorf("Expected some but not all pings to fail, %d of %d did", failures, len(a))
+	}
+}
+
+func TestFaultsOnlyHitTheirEndpoint(t *testing.T) {
+	_, client := newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{
+		"GET " + tickerRoute: {{Kind: FaultServerError, Probability: 1}},
+	}})
+	ctx := context.Background()
+
+	err := client.PokeAPI(ctx)
+	if err != nil {
+		t.Errorf("Ping should be fine, got %v", err)
+	}
+	_, err = client.GetTicker(ctx, "DOGE-USD")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the ticker to fail with a 500, got %v", err)
+	}
+}
+
+func TestEveryFaultKind(t *testing.T) {
+	ctx := context.Background()
+
+	_, client := newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{AnyEndpoint: {{Kind: FaultThrottle, Probability: 1}}}})
+	_, err := client.GetTicker(ctx, "DOGE-USD")
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("Expected throttled, got %v", err)
+	}
+
+	_, client = newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{tickerRoute: {{Kind: FaultMalformed, Probability: 1}}}})
+	_, err = client.GetTicker(ctx, "DOGE-USD")
+	var apiErr *APIError
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("Expected the cut off json not to decode, got %v", err)
+	}
+
+	_, client = newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{tickerRoute: {{Kind: FaultDrop, Probability: 1}}}})
+	_, err = client.GetTicker(ctx, "DOGE-USD")
+	if err == nil {
+		t.Error("Expected a dropped connection to fail")
+	}
+
+	_, client = newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{tickerRoute: {{Kind: FaultLatency, Probability: 1, Duration: time.Second}}}})
+	shortCtx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	_, err = client.GetTicker(shortCtx, "DOGE-USD")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the slow answer to time out, got %v", err)
+	}
+
+	_, client = newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{AnyEndpoint: {{Kind: FaultStaleCandles, Probability: 1, Duration: time.Hour}}}})
+	candles, err := client.GetCandles(ctx, "DOGE-USD", CandleIntervals["1min"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := candles[len(candles)-1].StartsAt; !last.Equal(testScenarioStart.Add(-time.Hour)) {
+		t.Errorf("Last stale candle started at %s", last)
+	}
+
+	server, _ := newFaultyExchange(t, FaultProfile{Endpoints: map[string][]Fault{AnyEndpoint: {{Kind: FaultClockSkew, Probability: 1, Duration: time.Minute}}}})
+	signed := NewClient(WithBaseURL(server.URL), WithCredentials("key", "secret"), WithRateLimiter(nil), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	_, err = signed.GetBalances(ctx)
+	if !errors.Is(err, ErrInvalidTimestamp) {
+		t.Errorf("Expected a skewed clock to reject the signature's timestamp, got %v", err)
+	}
+	err = signed.PokeAPI(ctx)
+	if err != nil {
+		t.Errorf("Unsigned requests have no timestamp to reject, got %v", err)
+	}
+}
+
+func TestLoadScenarioFaults(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCandles(t, dir, "DOGE-USD", "0.05")
+	config := `{"Data": ".", "Faults": {"Seed": 7, "Endpoints": {"POST /v3/orders": [{"Kind": "latency", "Probability": 0.25, "Duration": "250ms"}]}}}`
+	err := ioutil.WriteFile(filepath.Join(dir, "scenario.json"), []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenario, err := LoadScenario(filepath.Join(dir, "scenario.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	faults := scenario.Faults.Endpoints["POST /v3/orders"]
+	if scenario.Faults.Seed != 7 || len(faults) != 1 || faults[0].Duration != time.Millisecond*250 || faults[0].Probability != 0.25 {
+		t.Errorf("Faults were %+v", scenario.Faults)
+	}
+
+	config = `{"Data": ".", "Faults": {"Endpoints": {"*": [{"Kind": "gremlins", "Probability": 1}]}}}`
+	err = ioutil.WriteFile(filepath.Join(dir, "scenario.json"), []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadScenario(filepath.Join(dir, "scenario.json"))
+	if err == nil {
+		t.Error("Expected an unknown fault to be refused")
+	}
+}
@@ -0,0 +1,169 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// mockOrderBookSequence is where every mock order book starts counting
+	mockOrderBookSequence int64 = 1
+	// knownMockMarkets are the real rules of markets scenarios usually replay
+	knownMockMarkets = []MarketInfoResponse{
+		{
+			Symbol:              "BTC-USD",
+			BaseCurrencySymbol:  "BTC",
+			QuoteCurrencySymbol: "USD",
+			MinTradeSize:        decimal.RequireFromString("0.0001"),
+			Precision:           3,
+			Status:              "ONLINE",
+			CreatedAt:           time.Date(2018, 5, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Symbol:              "DOGE-USD",
+			BaseCurrencySymbol:  "DOGE",
+			QuoteCurrencySymbol: "USD",
+			MinTradeSize:        decimal.RequireFromString("50"),
+			Precision:           5,
+			Status:              "ONLINE",
+			CreatedAt:           time.Date(2019, 6, 18, 0, 0, 0, 0, time.UTC),
+		},
+	}
+)
+
+// mockMarketInfo is a known market's rules, or permissive ones made up from the symbol
+func mockMarketInfo(symbol string) MarketInfoResponse {
+	for _, market := range knownMockMarkets {
+		if market.Symbol == symbol {
+			return market
+		}
+	}
+	base, quote := symbol, ""
+	if parts := strings.SplitN(symbol, "-", 2); len(parts) == 2 {
+		base, quote = parts[0], parts[1]
+	}
+	return MarketInfoResponse{
+		Symbol:              symbol,
+		BaseCurrencySymbol:  base,
+		QuoteCurrencySymbol: quote,
+		MinTradeSize:        decimal.RequireFromString("0.00000001"),
+		Precision:           QuantityPrecision,
+		Status:              "ONLINE",
+		CreatedAt:           time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// mockTickSize is the smallest step a market's prices take, which is how far apart the mock order book's levels
+// and trades are
+func mockTickSize(market MarketInfoResponse) decimal.Decimal {
+	return decimal.New(1, -market.Precision)
+}
+
+func (e *MockExchange) findMarket(symbol string) (MarketInfoResponse, bool) {
+	for _, market := range e.markets {
+		if market.Symbol == symbol {
+			return market, true
+		}
+	}
+	return MarketInfoResponse{}, false
+}
+
+// tickSize is a symbol's mock tick, from the market the exchange has for it
+func (e *MockExchange) tickSize(symbol string) decimal.Decimal {
+	market, _ := e.findMarket(symbol)
+	return mockTickSize(market)
+}
+
+func (e *MockExchange) getMarkets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.markets)
+}
+
+func (e *MockExchange) getMarketInfo(w http.ResponseWriter, r *http.Request) {
+	market, ok := e.findMarket(mux.Vars(r)["symbol"])
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(market)
+}
+
+// mockOrderBook is levels one tick apart out from mid, with more quantity the further out they are. The best bid
+// and ask are both at mid, so a limit order at the last price fills whichever side it's on, the way a backtest
+// expects to trade at the close. Bids stop short of zero, so a cheap market's book has fewer of them.
+func mockOrderBook(mid decimal.Decimal, tick decimal.Decimal, depth int) OrderBookResponse {
+	book := OrderBookResponse{
+		Bid:      make([]OrderBookEntry, 0, depth),
+		Ask:      make([]OrderBookEntry, 0, depth),
+		Sequence: mockOrderBookSequence,
+	}
+	for i := 0; i < depth; i++ {
+		offset := tick.Mul(decimal.NewFromInt(int64(i)))
+		quantity := decimal.NewFromInt(int64(1000 + 10*i))
+		if bid := mid.Sub(offset); bid.IsPositive() {
+			book.Bid = append(book.Bid, OrderBookEntry{Quantity: quantity, Rate: bid})
+		}
+		book.Ask = append(book.Ask, OrderBookEntry{Quantity: quantity, Rate: mid.Add(offset)})
+	}
+	return book
+}
+
+// mockTrades alternates buy and sell takers a minute apart up to now, walking the rate up from mid a tick at a time
+func mockTrades(symbol string, mid decimal.Decimal, tick decimal.Decimal, now time.Time) []TradeResponse {
+	trades := make([]TradeResponse, 0, 10)
+	for i := 0; i < 10; i++ {
+		side := "BUY"
+		if i%2 == 1 {
+			side = "SELL"
+		}
+		trades = append(trades, TradeResponse{
+			ID:         fmt.Sprintf("%s-trade-%d", symbol, now.Add(time.Duration(i-9)*time.Minute).Unix()),
+			ExecutedAt: now.Add(time.Duration(i-9) * time.Minute),
+			Quantity:   decimal.NewFromInt(int64(100 * (i + 1))),
+			Rate:       mid.Add(tick.Mul(decimal.NewFromInt(int64(i)))),
+			TakerSide:  side,
+		})
+	}
+	return trades
+}
+
+func (e *MockExchange) getOrderBook(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+	mid, ok := e.midRate(symbol)
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	depth := 25
+	if value := r.URL.Query().Get("depth"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || !validDepth(parsed) {
+			writeMockError(w, http.StatusBadRequest, "INVALID_DEPTH")
+			return
+		}
+		depth = parsed
+	}
+	book := mockOrderBook(mid, e.tickSize(symbol), depth)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Sequence", strconv.FormatInt(book.Sequence, 10))
+	json.NewEncoder(w).Encode(book)
+}
+
+func (e *MockExchange) getTrades(w http.ResponseWriter, r *http.Request) {
+	symbol := mux.Vars(r)["symbol"]
+	mid, ok := e.midRate(symbol)
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mockTrades(symbol, mid, e.tickSize(symbol), e.Now()))
+}
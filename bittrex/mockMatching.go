@@ -0,0 +1,183 @@
+package bittrex
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// mockTimesInForce are the times in force the mock exchange knows, and whether they let an order rest on the book
+	mockTimesInForce = map[string]bool{
+		"GOOD_TIL_CANCELLED":           true,
+		"POST_ONLY_GOOD_TIL_CANCELLED": true,
+		"IMMEDIATE_OR_CANCEL":          false,
+		"FILL_OR_KILL":                 false,
+	}
+	// mockBookDepth is how deep orders can eat into the mock order book
+	mockBookDepth = OrderBookDepths[len(OrderBookDepths)-1]
+)
+
+// mockFill is part of an order matching at one rate
+type mockFill struct {
+	quantity decimal.Decimal
+	rate     decimal.Decimal
+	taker    bool
+}
+
+func sumFills(fills []mockFill) (decimal.Decimal, decimal.Decimal) {
+	quantity, value := decimal.Zero, decimal.Zero
+	for _, fill := range fills {
+		quantity = quantity.Add(fill.quantity)
+		value = value.Add(fill.quantity.Mul(fill.rate))
+	}
+	return quantity, value
+}
+
+// crossBook is what an incoming order takes from the mock order book right away. Market orders walk the
+// book until they are filled, limit orders stop at their limit.
+func crossBook(order NewOrder, mid decimal.Decimal, tick decimal.Decimal) []mockFill {
+	book := mockOrderBook(mid, tick, mockBookDepth)
+	levels := book.Ask
+	if order.Direction == "SELL" {
+		levels = book.Bid
+	}
+	fills := make([]mockFill, 0)
+	remaining := order.Quantity
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		if order.Type == "LIMIT" {
+			if order.Direction == "BUY" && level.Rate.GreaterThan(order.Limit) {
+				break
+			}
+			if order.Direction == "SELL" && level.Rate.LessThan(order.Limit) {
+				break
+			}
+		}
+		quantity := decimal.Min(remaining, level.Quantity)
+		fills = append(fills, mockFill{quantity: quantity, rate: level.Rate, taker: true})
+		remaining = remaining.Sub(quantity)
+	}
+	return fills
+}
+
+// available is a currency's balance less what open orders have set aside. Callers hold the lock.
+func (e *MockExchange) available(currency string) decimal.Decimal {
+	available := e.balances[currency]
+	for _, order := range e.orders {
+		if order.Status != "OPEN" {
+			continue
+		}
+		held, amount := e.reserved(order)
+		if held == currency {
+			available = available.Sub(amount)
+		}
+	}
+	return available
+}
+
+// reserved is the currency and amount an open order has set aside for the rest of its quantity
+func (e *MockExchange) reserved(order OrderResponse) (string, decimal.Decimal) {
+	market, _ := e.findMarket(order.MarketSymbol)
+	remaining := order.Quantity.Sub(order.FillQuantity)
+	if order.Direction == "SELL" {
+		return market.BaseCurrencySymbol, remaining
+	}
+	return market.QuoteCurrencySymbol, remaining.Mul(order.Limit).Mul(decimal.NewFromInt(1).Add(e.scenario.Commission))
+}
+
+// fill moves a match through the account and onto the order, charging commission in the quote currency.
+// Callers hold the lock.
+func (e *MockExchange) fill(order *OrderResponse, fill mockFill, now time.Time) {
+	market, _ := e.findMarket(order.MarketSymbol)
+	value := fill.quantity.Mul(fill.rate)
+	commission := value.Mul(e.scenario.Commission)
+	base, quote := market.BaseCurrencySymbol, market.QuoteCurrencySymbol
+	if order.Direction == "BUY" {
+		e.balances[base] = e.balances[base].Add(fill.quantity)
+		e.balances[quote] = e.balances[quote].Sub(value).Sub(commission)
+	} else {
+		e.balances[base] = e.balances[base].Sub(fill.quantity)
+		e.balances[quote] = e.balances[quote].Add(value).Sub(commission)
+	}
+
+	order.FillQuantity = order.FillQuantity.Add(fill.quantity)
+	order.Proceeds = order.Proceeds.Add(value)
+	order.Commission = order.Commission.Add(commission)
+	order.UpdatedAt = now
+	if order.FillQuantity.Equal(order.Quantity) {
+		order.Status = "CLOSED"
+		order.ClosedAt = now
+	}
+
+	id, _ := newClientOrderID()
+	e.executions = append(e.executions, ExecutionResponse{
+		ID:           id,
+		MarketSymbol: order.MarketSymbol,
+		ExecutedAt:   now,
+		Quantity:     fill.quantity,
+		Rate:         fill.rate,
+		OrderID:      order.ID,
+		Commission:   commission,
+		IsTaker:      fill.taker,
+	})
+}
+
+// matchResting fills open orders that a new candle traded through, at their limit. It returns the orders that
+// changed and the currencies whose balances moved.
+func (e *MockExchange) matchResting(symbol string, candles []CandleResponse) ([]OrderResponse, []BalanceResponse) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	changed := make([]OrderResponse, 0)
+	for _, candle := range candles {
+		for i := range e.orders {
+			order := &e.orders[i]
+			if order.Status != "OPEN" || order.MarketSymbol != symbol {
+				continue
+			}
+			crossed := order.Direction == "BUY" && !candle.Low.GreaterThan(order.Limit) ||
+				order.Direction == "SELL" && !candle.High.LessThan(order.Limit)
+			if !crossed {
+				continue
+			}
+			e.fill(order, mockFill{quantity: order.Quantity.Sub(order.FillQuantity), rate: order.Limit}, e.now)
+			changed = append(changed, *order)
+		}
+	}
+	return changed, e.marketBalances(changed)
+}
+
+// marketBalances is the balance of every currency the orders trade. Callers hold the lock.
+func (e *MockExchange) marketBalances(orders []OrderResponse) []BalanceResponse {
+	seen := map[string]bool{}
+	ret := make([]BalanceResponse, 0)
+	for _, order := range orders {
+		market, _ := e.findMarket(order.MarketSymbol)
+		for _, currency := range []string{market.BaseCurrencySymbol, market.QuoteCurrencySymbol} {
+			if seen[currency] {
+				continue
+			}
+			seen[currency] = true
+			ret = append(ret, e.balance(currency))
+		}
+	}
+	return ret
+}
+
+// balance is one currency's balance. Callers hold the lock.
+func (e *MockExchange) balance(currency string) BalanceResponse {
+	return BalanceResponse{CurrencySymbol: currency, Total: e.balances[currency], Available: e.available(currency), UpdatedAt: e.now}
+}
+
+// publishChanges pushes order and balance changes to stream subscribers
+func (e *MockExchange) publishChanges(orders []OrderResponse, balances []BalanceResponse) {
+	for _, order := range orders {
+		e.stream.PublishOrder(order)
+	}
+	for _, balance := range balances {
+		e.stream.PublishBalance(balance)
+	}
+}
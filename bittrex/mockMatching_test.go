@@ -0,0 +1,180 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func dogeOrder(direction string, orderType string, quantity string, limit string, timeInForce string) NewOrder {
+	order := NewOrder{MarketSymbol: "DOGE-USD", Direction: direction, Type: orderType, Quantity: decimal.RequireFromString(quantity), TimeInForce: timeInForce}
+	if limit != "" {
+		order.Limit = decimal.RequireFromString(limit)
+	}
+	return order
+}
+
+func balanceOf(t *testing.T, client *Client, currency string) BalanceResponse {
+	balances, err := client.GetBalances(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, balance := range balances {
+		if balance.CurrencySymbol == currency {
+			return balance
+		}
+	}
+	return BalanceResponse{CurrencySymbol: currency}
+}
+
+func TestMockMarketOrdersWalkTheBook(t *testing.T) {
+	scenario := testScenario(t)
+	scenario.Commission = decimal.RequireFromString("0.0075")
+	exchange, err := NewMockExchange(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(exchange.Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil), WithRetryPolicy(fastRetries))
+	ctx := context.Background()
+
+	order, err := client.Order(ctx, dogeOrder("BUY", "MARKET", "1500", "", "IMMEDIATE_OR_CANCEL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1000 at the first ask, which is the close, and 500 a tick above it
+	if order.Status != "CLOSED" || order.FillQuantity.String() != "1500" || order.Proceeds.String() != "75.005" || order.Commission.String() != "0.5625375" {
+		t.Errorf("Market order came back as %+v", order)
+	}
+	if balanceOf(t, client, "DOGE").Total.String() != "1500" || balanceOf(t, client, "USD").Total.String() != "924.4324625" {
+		t.Errorf("Balances were %+v and %+v", balanceOf(t, client, "DOGE"), balanceOf(t, client, "USD"))
+	}
+	executions, err := client.GetExecutions(ctx, "DOGE-USD", Paging{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 2 || executions[1].Rate.String() != "0.05001" || !executions[1].IsTaker {
+		t.Errorf("Executions were %+v", executions)
+	}
+
+	_, err = client.Order(ctx, dogeOrder("BUY", "MARKET", "100", "", "GOOD_TIL_CANCELLED"))
+	if err == nil {
+		t.Error("Expected a market order that could rest to be refused")
+	}
+	_, err = client.Order(ctx, dogeOrder("SELL", "MARKET", "5000", "", "IMMEDIATE_OR_CANCEL"))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected selling more DOGE than there is to be insufficient funds, got %v", err)
+	}
+}
+
+func TestMockTimesInForce(t *testing.T) {
+	exchange := newTestExchange(t)
+	server := httptest.NewServer(exchange.Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil), WithRetryPolicy(fastRetries))
+	ctx := context.Background()
+
+	// Only 1000 sits at the 0.05 close
+	killed, err := client.Order(ctx, dogeOrder("BUY", "LIMIT", "2000", "0.05", "FILL_OR_KILL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if killed.Status != "CLOSED" || !killed.FillQuantity.IsZero() {
+		t.Errorf("Fill or kill came back as %+v", killed)
+	}
+	partial, err := client.Order(ctx, dogeOrder("BUY", "LIMIT", "2000", "0.05", "IMMEDIATE_OR_CANCEL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partial.Status != "CLOSED" || partial.FillQuantity.String() != "1000" {
+		t.Errorf("Immediate or cancel came back as %+v", partial)
+	}
+	resting, err := client.Order(ctx, dogeOrder("BUY", "LIMIT", "2000", "0.05", "GOOD_TIL_CANCELLED"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resting.Status != "OPEN" || resting.FillQuantity.String() != "1000" {
+		t.Errorf("Good til cancelled came back as %+v", resting)
+	}
+
+	_, err = client.Order(ctx, dogeOrder("BUY", "LIMIT", "100", "0.06", "POST_ONLY_GOOD_TIL_CANCELLED"))
+	if err == nil {
+		t.Error("Expected a post only order that would take to be refused")
+	}
+	posted, err := client.Order(ctx, dogeOrder("BUY", "LIMIT", "1000", "0.049", "POST_ONLY_GOOD_TIL_CANCELLED"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted.Status != "OPEN" || !posted.FillQuantity.IsZero() {
+		t.Errorf("Post only came back as %+v", posted)
+	}
+
+	// 2 * 1000 * 0.05 spent and 1000 * 0.05 + 1000 * 0.049 held by the two open orders
+	usd := balanceOf(t, client, "USD")
+	if usd.Total.String() != "900" || usd.Available.String() != "801" {
+		t.Errorf("USD balance was %+v", usd)
+	}
+	_, err = client.Order(ctx, dogeOrder("BUY", "LIMIT", "20000", "0.049", "GOOD_TIL_CANCELLED"))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected held funds to be unavailable, got %v", err)
+	}
+
+	// A dip to 0.0485 trades through both resting orders
+	key := scenarioKey("DOGE-USD", CandleIntervals["1min"])
+	for i, candle := range exchange.candles[key] {
+		if candle.StartsAt.Equal(testScenarioStart) {
+			exchange.candles[key][i].Low = decimal.RequireFromString("0.0485")
+		}
+	}
+	exchange.Advance(time.Minute)
+
+	for _, id := range []string{resting.ID, posted.ID} {
+		order, err := client.GetOrder(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order.Status != "CLOSED" || !order.FillQuantity.Equal(order.Quantity) {
+			t.Errorf("Resting order came back as %+v", order)
+		}
+	}
+	if balanceOf(t, client, "DOGE").Total.String() != "4000" {
+		t.Errorf("DOGE balance was %+v", balanceOf(t, client, "DOGE"))
+	}
+}
+
+func TestMockBookStopsAtZeroForCheapMarkets(t *testing.T) {
+	mid := decimal.RequireFromString("0.003")
+	tick := mockTickSize(mockMarketInfo("DOGE-USD"))
+	fills := crossBook(dogeOrder("SELL", "MARKET", "10000000", "", "IMMEDIATE_OR_CANCEL"), mid, tick)
+	// Only 0.003 down to 0.00001 are above zero
+	if len(fills) != 300 {
+		t.Errorf("Filled at %d levels, expected 300", len(fills))
+	}
+	for _, fill := range fills {
+		if !fill.rate.IsPositive() {
+			t.Fatalf("Filled %s at %s", fill.quantity, fill.rate)
+		}
+	}
+	if len(mockOrderBook(mid, tick, mockBookDepth).Ask) != mockBookDepth {
+		t.Error("Expected the asks to go the whole depth")
+	}
+}
+
+func TestMockLimitOrdersAtTheCloseFill(t *testing.T) {
+	mid := decimal.RequireFromString("0.05")
+	tick := mockTickSize(mockMarketInfo("DOGE-USD"))
+	if tick.String() != "0.00001" {
+		t.Errorf("Tick was %s, expected DOGE-USD's precision", tick)
+	}
+	for _, direction := range []string{"BUY", "SELL"} {
+		fills := crossBook(dogeOrder(direction, "LIMIT", "100", "0.05", "IMMEDIATE_OR_CANCEL"), mid, tick)
+		if len(fills) != 1 || !fills[0].rate.Equal(mid) {
+			t.Errorf("%s at the close filled %+v", direction, fills)
+		}
+	}
+}
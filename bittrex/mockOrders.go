@@ -0,0 +1,232 @@
+package bittrex
+
+import (
+	"cryptofu/exchange"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+func writeMockError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code})
+}
+
+// postOrder matches a new order against the mock order book around the last close. Whatever doesn't match
+// right away rests on the book if its time in force allows, and fills at its limit once a candle trades
+// through it. Funds are checked against the virtual balances less what open orders have set aside.
+func (e *MockExchange) postOrder(w http.ResponseWriter, r *http.Request) {
+	var newOrder NewOrder
+	err := json.NewDecoder(r.Body).Decode(&newOrder)
+	if err != nil {
+		writeMockError(w, http.StatusBadRequest, "BAD_REQUEST")
+		return
+	}
+	market, ok := e.findMarket(newOrder.MarketSymbol)
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "MARKET_DOES_NOT_EXIST")
+		return
+	}
+	validated, err := exchange.ValidateOrder(exchange.OrderRequest{
+		Symbol:        newOrder.MarketSymbol,
+		Side:          newOrder.Direction,
+		Type:          newOrder.Type,
+		Quantity:      newOrder.Quantity,
+		Limit:         newOrder.Limit,
+		TimeInForce:   newOrder.TimeInForce,
+		ClientOrderID: newOrder.ClientOrderID,
+	}, toMarketInfo(market))
+	if errors.Is(err, ErrMinTradeRequirementNotMet) {
+		writeMockError(w, http.StatusBadRequest, "MIN_TRADE_REQUIREMENT_NOT_MET")
+		return
+	} else if err != nil {
+		writeMockError(w, http.StatusBadRequest, "BAD_REQUEST")
+		return
+	}
+	newOrder.Quantity = validated.Quantity
+	newOrder.Limit = validated.Limit
+	rests, ok := mockTimesInForce[newOrder.TimeInForce]
+	if !ok || newOrder.Type != "LIMIT" && newOrder.Type != "MARKET" {
+		writeMockError(w, http.StatusBadRequest, "BAD_REQUEST")
+		return
+	}
+	if newOrder.Type == "MARKET" && rests {
+		// Market orders have nothing to rest at
+		writeMockError(w, http.StatusBadRequest, "INVALID_TIME_IN_FORCE")
+		return
+	}
+	mid, ok := e.midRate(newOrder.MarketSymbol)
+	if !ok {
+		writeMockError(w, http.StatusBadRequest, "MARKET_OFFLINE")
+		return
+	}
+	id, err := newClientOrderID()
+	if err != nil {
+		writeMockError(w, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+
+	fills := crossBook(newOrder, mid, e.tickSize(newOrder.MarketSymbol))
+	filled, value := sumFills(fills)
+	switch {
+	case newOrder.TimeInForce == "POST_ONLY_GOOD_TIL_CANCELLED" && len(fills) > 0:
+		writeMockError(w, http.StatusBadRequest, "POST_ONLY_WOULD_TAKE")
+		return
+	case newOrder.TimeInForce == "FILL_OR_KILL" && filled.LessThan(newOrder.Quantity):
+		fills = []mockFill{}
+	}
+
+	e.mu.Lock()
+	now := e.now
+	currency, needed := market.BaseCurrencySymbol, newOrder.Quantity
+	if newOrder.Direction == "BUY" {
+		currency = market.QuoteCurrencySymbol
+		if newOrder.Type == "MARKET" {
+			needed = value
+		} else {
+			needed = newOrder.Quantity.Mul(newOrder.Limit)
+		}
+		needed = needed.Mul(decimal.NewFromInt(1).Add(e.scenario.Commission))
+	}
+	if e.available(currency).LessThan(needed) {
+		e.mu.Unlock()
+		writeMockError(w, http.StatusBadRequest, "INSUFFICIENT_FUNDS")
+		return
+	}
+
+	order := OrderResponse{
+		ID:            id,
+		MarketSymbol:  newOrder.MarketSymbol,
+		Direction:     newOrder.Direction,
+		Type:          newOrder.Type,
+		Quantity:      newOrder.Quantity,
+		Limit:         newOrder.Limit,
+		TimeInForce:   newOrder.TimeInForce,
+		ClientOrderID: newOrder.ClientOrderID,
+		FillQuantity:  decimal.Zero,
+		Commission:    decimal.Zero,
+		Proceeds:      decimal.Zero,
+		Status:        "OPEN",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	for _, fill := range fills {
+		e.fill(&order, fill, now)
+	}
+	if !rests && order.Status == "OPEN" {
+		order = closeMockOrder(order, now)
+	}
+	e.orders = append(e.orders, order)
+	balances := e.marketBalances([]OrderResponse{order})
+	e.mu.Unlock()
+	e.publishChanges([]OrderResponse{order}, balances)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (e *MockExchange) getOrder(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, order := range e.orders {
+		if order.ID == mux.Vars(r)["id"] {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(order)
+			return
+		}
+	}
+	writeMockError(w, http.StatusNotFound, "NOT_FOUND")
+}
+
+func (e *MockExchange) deleteOrder(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	for i, order := range e.orders {
+		if order.ID != mux.Vars(r)["id"] {
+			continue
+		}
+		if order.Status == "OPEN" {
+			e.orders[i] = closeMockOrder(order, e.now)
+		}
+		order = e.orders[i]
+		balances := e.marketBalances([]OrderResponse{order})
+		e.mu.Unlock()
+		e.publishChanges([]OrderResponse{order}, balances)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(order)
+		return
+	}
+	e.mu.Unlock()
+	writeMockError(w, http.StatusNotFound, "NOT_FOUND")
+}
+
+func (e *MockExchange) getOpenOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.filterOrders(r.URL.Query().Get("marketSymbol"), "OPEN"))
+}
+
+func (e *MockExchange) getClosedOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.filterOrders(r.URL.Query().Get("marketSymbol"), "CLOSED"))
+}
+
+func (e *MockExchange) deleteOpenOrders(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	marketSymbol := r.URL.Query().Get("marketSymbol")
+	response := make([]BulkCancelResult, 0)
+	canceled := make([]OrderResponse, 0)
+	for i, order := range e.orders {
+		if order.Status == "OPEN" && (marketSymbol == "" || order.MarketSymbol == marketSymbol) {
+			e.orders[i] = closeMockOrder(order, e.now)
+			response = append(response, BulkCancelResult{ID: order.ID, StatusCode: "SUCCESS", Result: e.orders[i]})
+			canceled = append(canceled, e.orders[i])
+		}
+	}
+	balances := e.marketBalances(canceled)
+	e.mu.Unlock()
+	e.publishChanges(canceled, balances)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (e *MockExchange) getExecutions(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	marketSymbol := r.URL.Query().Get("marketSymbol")
+	response := make([]ExecutionResponse, 0)
+	for _, execution := range e.executions {
+		if marketSymbol == "" || execution.MarketSymbol == marketSymbol {
+			response = append(response, execution)
+		}
+	}
+	e.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func closeMockOrder(order OrderResponse, now time.Time) OrderResponse {
+	order.Status = "CLOSED"
+	order.UpdatedAt = now
+	order.ClosedAt = now
+	return order
+}
+
+func (e *MockExchange) filterOrders(marketSymbol string, status string) []OrderResponse {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ret := make([]OrderResponse, 0)
+	for _, order := range e.orders {
+		if order.Status == status && (marketSymbol == "" || order.MarketSymbol == marketSymbol) {
+			ret = append(ret, order)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,241 @@
+package bittrex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// MockStreamServer is a local stand-in for the bittrex websocket. It speaks the same SignalR handshake and
+// payload encoding. Publish pushes to every connection subscribed to a channel, while SkipSequence and
+// DropConnections break things on purpose so gap and reconnect handling can be tested offline.
+type MockStreamServer struct {
+	mu          sync.Mutex
+	upgrader    websocket.Upgrader
+	connections map[*mockStreamConn]bool
+	sequences   map[string]int64
+	tokens      int
+}
+
+type mockStreamConn struct {
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	channels map[string]bool
+}
+
+func (c *mockStreamConn) write(message interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(message)
+}
+
+// NewMockStreamServer makes a stream stand-in with no connections. Mount it at the socket url's path.
+func NewMockStreamServer() *MockStreamServer {
+	return &MockStreamServer{
+		connections: map[*mockStreamConn]bool{},
+		sequences:   map[string]int64{},
+	}
+}
+
+func (m *MockStreamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch path.Base(r.URL.Path) {
+	case "negotiate":
+		m.mu.Lock()
+		m.tokens++
+		token := fmt.Sprintf("mock-token-%d", m.tokens)
+		m.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"ConnectionToken": token,
+			"ConnectionId":    token,
+			"ProtocolVersion": socketProtocol,
+			"TryWebSockets":   true,
+		})
+	case "connect":
+		m.serveConnection(w, r)
+	case "start":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"Response": "started"})
+	default:
+		writeMockError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func (m *MockStreamServer) serveConnection(w http.ResponseWriter, r *http.Request) {
+	conn, err := m.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	streamConn := &mockStreamConn{conn: conn, channels: map[string]bool{}}
+	m.mu.Lock()
+	m.connections[streamConn] = true
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.connections, streamConn)
+		m.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var invocation struct {
+			H string
+			M string
+			A []json.RawMessage
+			I int
+		}
+		err := conn.ReadJSON(&invocation)
+		if err != nil {
+			return
+		}
+		response := map[string]interface{}{"I": fmt.Sprintf("%d", invocation.I)}
+		switch invocation.M {
+		case "Authenticate":
+			// There is no secret to check against, any signature will do
+			response["R"] = socketResult{Success: true}
+		case "Subscribe":
+			var channels []string
+			if len(invocation.A) > 0 {
+				json.Unmarshal(invocation.A[0], &channels)
+			}
+			results := make([]socketResult, 0, len(channels))
+			m.mu.Lock()
+			for _, channel := range channels {
+				if !knownMockChannel(channel) {
+					results = append(results, socketResult{Success: false, ErrorCode: "INVALID_CHANNEL"})
+					continue
+				}
+				streamConn.channels[channel] = true
+				results = append(results, socketResult{Success: true})
+			}
+			m.mu.Unlock()
+			response["R"] = results
+		default:
+			response["E"] = fmt.Sprintf("'%s' method could not be resolved", invocation.M)
+		}
+		if streamConn.write(response) != nil {
+			return
+		}
+	}
+}
+
+func knownMockChannel(channel string) bool {
+	if channel == OrderChannel || channel == BalanceChannel || channel == "heartbeat" {
+		return true
+	}
+	for _, prefix := range []string{"candle_", "ticker_", "trade_", "orderbook_"} {
+		if strings.HasPrefix(channel, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribers is how many connections are subscribed to a channel
+func (m *MockStreamServer) Subscribers(channel string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for conn := range m.connections {
+		if conn.channels[channel] {
+			count++
+		}
+	}
+	return count
+}
+
+// SkipSequence burns a channel's next sequence number so subscribers see a gap
+func (m *MockStreamServer) SkipSequence(channel string) {
+	m.nextSequence(channel)
+}
+
+// DropConnections hangs up on every connection, like bittrex going away
+func (m *MockStreamServer) DropConnections() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for conn := range m.connections {
+		conn.conn.Close()
+	}
+}
+
+// PublishCandle pushes a candle to subscribers of CandleChannel(symbol, interval)
+func (m *MockStreamServer) PublishCandle(symbol string, interval string, candle CandleResponse) error {
+	channel := CandleChannel(symbol, interval)
+	return m.publish(channel, "candle", candleDelta{Sequence: m.nextSequence(channel), MarketSymbol: symbol, Interval: interval, Delta: candle})
+}
+
+// PublishTicker pushes a ticker to subscribers of TickerChannel(ticker.Symbol)
+func (m *MockStreamServer) PublishTicker(ticker TickerResponse) error {
+	return m.publish(TickerChannel(ticker.Symbol), "ticker", ticker)
+}
+
+// PublishTrades pushes trades to subscribers of TradeChannel(symbol)
+func (m *MockStreamServer) PublishTrades(symbol string, trades []TradeResponse) error {
+	channel := TradeChannel(symbol)
+	return m.publish(channel, "trade", tradeDelta{Sequence: m.nextSequence(channel), MarketSymbol: symbol, Deltas: trades})
+}
+
+// PublishOrderBook pushes changed levels to subscribers of OrderBookChannel(symbol, depth)
+func (m *MockStreamServer) PublishOrderBook(symbol string, depth int, bids []OrderBookEntry, asks []OrderBookEntry) error {
+	channel := OrderBookChannel(symbol, depth)
+	return m.publish(channel, "orderBook", orderBookDelta{MarketSymbol: symbol, Depth: depth, Sequence: m.nextSequence(channel), BidDeltas: bids, AskDeltas: asks})
+}
+
+// PublishOrder pushes an order change to subscribers of OrderChannel
+func (m *MockStreamServer) PublishOrder(order OrderResponse) error {
+	return m.publish(OrderChannel, "order", orderDelta{AccountID: "Test User", Sequence: m.nextSequence(OrderChannel), Delta: order})
+}
+
+// PublishBalance pushes a balance change to subscribers of BalanceChannel
+func (m *MockStreamServer) PublishBalance(balance BalanceResponse) error {
+	return m.publish(BalanceChannel, "balance", balanceDelta{AccountID: "Test User", Sequence: m.nextSequence(BalanceChannel), Delta: balance})
+}
+
+// nextSequence counts up per channel. Order books start where the mock REST order book says it is.
+func (m *MockStreamServer) nextSequence(channel string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sequences[channel]; !ok && strings.HasPrefix(channel, "orderbook_") {
+		m.sequences[channel] = mockOrderBookSequence
+	}
+	m.sequences[channel]++
+	return m.sequences[channel]
+}
+
+func (m *MockStreamServer) publish(channel string, method string, payload interface{}) error {
+	m.mu.Lock()
+	subscribers := make([]*mockStreamConn, 0)
+	for conn := range m.connections {
+		if conn.channels[channel] {
+			subscribers = append(subscribers, conn)
+		}
+	}
+	m.mu.Unlock()
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeSocketPayload(content)
+	if err != nil {
+		return err
+	}
+	message := socketMessage{
+		C: fmt.Sprintf("d-%s", channel),
+		M: []socketPush{{H: "C3", M: method, A: []string{encoded}}},
+	}
+	for _, conn := range subscribers {
+		// A subscriber that hung up is not the publisher's problem
+		conn.write(message)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GetOrder looks up one of your orders
+func (c *Client) GetOrder(ctx context.Context, orderID string) (OrderResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/orders/%s", c.baseURL, APIVersion, url.PathEscape(orderID))
+	resp, err := c.get(ctx, endpoint, true)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+
+	var ret OrderResponse
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// CancelOrder cancels one of your open orders and returns what is left of it
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (OrderResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/orders/%s", c.baseURL, APIVersion, url.PathEscape(orderID))
+	resp, err := c.delete(ctx, endpoint, true)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+
+	var ret OrderResponse
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// ListOpenOrders lists your open orders for a symbol, or every market if symbol is empty
+func (c *Client) ListOpenOrders(ctx context.Context, symbol string) ([]OrderResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/orders/open%s", c.baseURL, APIVersion, marketQuery(symbol, Paging{}))
+	resp, err := c.get(ctx, endpoint, true)
+	if err != nil {
+		return []OrderResponse{}, err
+	}
+
+	ret := make([]OrderResponse, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []OrderResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// ListClosedOrders lists your closed orders for a symbol, or every market if symbol is empty
+func (c *Client) ListClosedOrders(ctx context.Context, symbol string, paging Paging) ([]OrderResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/orders/closed%s", c.baseURL, APIVersion, marketQuery(symbol, paging))
+	resp, err := c.get(ctx, endpoint, true)
+	if err != nil {
+		return []OrderResponse{}, err
+	}
+
+	ret := make([]OrderResponse, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []OrderResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// CancelAllOrders cancels every open order for a symbol, or every market if symbol is empty
+func (c *Client) CancelAllOrders(ctx context.Context, symbol string) ([]BulkCancelResult, error) {
+	endpoint := fmt.Sprintf("%s/%s/orders/open%s", c.baseURL, APIVersion, marketQuery(symbol, Paging{}))
+	resp, err := c.delete(ctx, endpoint, true)
+	if err != nil {
+		return []BulkCancelResult{}, err
+	}
+
+	ret := make([]BulkCancelResult, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []BulkCancelResult{}, err
+	}
+
+	return ret, nil
+}
+
+// GetExecutions lists the fills of your orders for a symbol, or every market if symbol is empty
+func (c *Client) GetExecutions(ctx context.Context, symbol string, paging Paging) ([]ExecutionResponse, error) {
+	endpoint := fmt.Sprintf("%s/%s/executions%s", c.baseURL, APIVersion, marketQuery(symbol, paging))
+	resp, err := c.get(ctx, endpoint, true)
+	if err != nil {
+		return []ExecutionResponse{}, err
+	}
+
+	ret := make([]ExecutionResponse, 0)
+	err = readJSON(resp, &ret)
+	if err != nil {
+		return []ExecutionResponse{}, err
+	}
+
+	return ret, nil
+}
+
+// FindOrder finds an order by the client order id it was placed with. It returns ErrNotFound if bittrex
+// never saw it. Only the most recent page of closed orders is searched.
+func (c *Client) FindOrder(ctx context.Context, symbol string, clientOrderID string) (OrderResponse, error) {
+	open, err := c.ListOpenOrders(ctx, symbol)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	for _, order := range open {
+		if order.ClientOrderID == clientOrderID {
+			return order, nil
+		}
+	}
+
+	closed, err := c.ListClosedOrders(ctx, symbol, Paging{PageSize: 200})
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	for _, order := range closed {
+		if order.ClientOrderID == clientOrderID {
+			return order, nil
+		}
+	}
+
+	return OrderResponse{}, ErrNotFound
+}
+
+func marketQuery(symbol string, paging Paging) string {
+	query := url.Values{}
+	if symbol != "" {
+		query.Set("marketSymbol", symbol)
+	}
+	if paging.NextPageToken != "" {
+		query.Set("nextPageToken", paging.NextPageToken)
+	}
+	if paging.PreviousPageToken != "" {
+		query.Set("previousPageToken", paging.PreviousPageToken)
+	}
+	if paging.PageSize != 0 {
+		query.Set("pageSize", strconv.Itoa(paging.PageSize))
+	}
+	if !paging.StartDate.IsZero() {
+		query.Set("startDate", paging.StartDate.UTC().Format(time.RFC3339))
+	}
+	if !paging.EndDate.IsZero() {
+		query.Set("endDate", paging.EndDate.UTC().Format(time.RFC3339))
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+// readJSON decodes a successful response into ret. It closes the response body.
+func readJSON(resp *http.Response, ret interface{}) error {
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, ret)
+}
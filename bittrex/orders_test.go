@@ -0,0 +1,80 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestOrderLifecycleAgainstMockServer(t *testing.T) {
+	server := httptest.NewServer(newTestExchange(t).Handler())
+	defer server.Close()
+	client := NewClient(WithBaseURL(server.URL), WithRateLimiter(nil))
+	ctx := context.Background()
+
+	filled, err := client.Order(ctx, NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY", Type: "LIMIT", Quantity: decimal.RequireFromString("100"), Limit: decimal.RequireFromString("0.06"), TimeInForce: "IMMEDIATE_OR_CANCEL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resting, err := client.Order(ctx, NewOrder{MarketSymbol: "DOGE-USD", Direction: "SELL", Type: "LIMIT", Quantity: decimal.RequireFromString("100"), Limit: decimal.RequireFromString("0.07"), TimeInForce: "GOOD_TIL_CANCELLED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetOrder(ctx, filled.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "CLOSED" || got.FillQuantity.String() != "100" {
+		t.Errorf("Filled order came back as %s with %s filled", got.Status, got.FillQuantity)
+	}
+
+	open, err := client.ListOpenOrders(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].ID != resting.ID {
+		t.Errorf("Open orders were %v", open)
+	}
+
+	found, err := client.FindOrder(ctx, "DOGE-USD", resting.ClientOrderID)
+	if err != nil || found.ID != resting.ID {
+		t.Errorf("Found %v, %v", found, err)
+	}
+	_, err = client.FindOrder(ctx, "DOGE-USD", "never-placed")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Was %v, expected not found", err)
+	}
+
+	canceled, err := client.CancelAllOrders(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 1 || canceled[0].Result.Status != "CLOSED" {
+		t.Errorf("Canceled %v", canceled)
+	}
+
+	closed, err := client.ListClosedOrders(ctx, "DOGE-USD", Paging{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 2 {
+		t.Errorf("Expected 2 closed orders, got %d", len(closed))
+	}
+
+	executions, err := client.GetExecutions(ctx, "DOGE-USD", Paging{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 1 || executions[0].OrderID != filled.ID {
+		t.Errorf("Executions were %v", executions)
+	}
+
+	_, err = client.CancelOrder(ctx, "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Was %v, expected not found", err)
+	}
+}
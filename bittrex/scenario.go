@@ -0,0 +1,346 @@
+package bittrex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// candleIntervalDurations is how long a candle of each interval lasts
+	candleIntervalDurations = map[string]time.Duration{
+		CandleIntervals["1min"]:  time.Minute,
+		CandleIntervals["5min"]:  time.Minute * 5,
+		CandleIntervals["1hour"]: time.Hour,
+		CandleIntervals["1day"]:  time.Hour * 24,
+	}
+	// recentCandlesWindows is how far back the recent candles endpoint reaches for each interval
+	recentCandlesWindows = map[string]time.Duration{
+		CandleIntervals["1min"]:  time.Hour * 24,
+		CandleIntervals["5min"]:  time.Hour * 24,
+		CandleIntervals["1hour"]: time.Hour * 24 * 31,
+		CandleIntervals["1day"]:  time.Hour * 24 * 366,
+	}
+)
+
+// RecentCandlesWindow is how far back GetCandles reaches for an interval
+func RecentCandlesWindow(interval string) time.Duration {
+	return recentCandlesWindows[interval]
+}
+
+// Scenario is what a MockExchange replays.
+//
+// Data is either a directory laid out like a candle store, <Data>/<symbol>/<interval>/*.jsonl(.gz) or *.csv,
+// or a single file of candles when the scenario has one symbol and one interval. Csv files have the columns
+// startsAt,open,high,low,close,volume,quoteVolume. The simulated clock starts at Start, moves forward Tick
+// every time the first symbol and interval's recent candles are asked for, and the scenario is over once it
+// passes End. Addr is where Start listens, by default any free port on loopback so mock exchanges running side
+// by side don't collide; URL says where it ended up.
+type Scenario struct {
+	Symbols   []string
+	Intervals []string
+	Data      string
+	Start     time.Time
+	End       time.Time
+	Tick      time.Duration
+	Addr      string
+	// Balances are what the account starts with, by currency
+	Balances map[string]decimal.Decimal
+	// Commission is the share of every fill's value charged in the quote currency
+	Commission decimal.Decimal
+	// Faults are how the exchange misbehaves, if at all
+	Faults FaultProfile
+}
+
+// DefaultScenario is the week of DOGE-USD minutes the mock server has always replayed
+func DefaultScenario() Scenario {
+	return Scenario{
+		Symbols:    []string{"DOGE-USD"},
+		Intervals:  []string{CandleIntervals["1min"]},
+		Data:       filepath.Join("data", "candles"),
+		Start:      time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2020, 6, 7, 0, 0, 0, 0, time.UTC),
+		Tick:       time.Minute,
+		Addr:       "127.0.0.1:0",
+		Balances:   map[string]decimal.Decimal{"USD": decimal.NewFromInt(1000)},
+		Commission: decimal.RequireFromString("0.0075"),
+	}
+}
+
+// scenarioFile is how a scenario is written down. Tick is a duration string like "5m".
+type scenarioFile struct {
+	Symbols    []string
+	Intervals  []string
+	Data       string
+	Start      time.Time
+	End        time.Time
+	Tick       string
+	Addr       string
+	Balances   map[string]decimal.Decimal
+	Commission *decimal.Decimal
+	Faults     *struct {
+		Seed      int64
+		Endpoints map[string][]faultFile
+	}
+}
+
+// faultFile is how a fault is written down. Duration is a duration string like "250ms".
+type faultFile struct {
+	Kind        string
+	Probability float64
+	Duration    string
+}
+
+// LoadScenario reads a scenario from a json file. Anything the file leaves out comes from DefaultScenario,
+// and a relative Data path is relative to the file.
+func LoadScenario(name string) (Scenario, error) {
+	scenario := DefaultScenario()
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return scenario, err
+	}
+	var file scenarioFile
+	err = json.Unmarshal(content, &file)
+	if err != nil {
+		return scenario, fmt.Errorf("reading scenario %s: %w", name, err)
+	}
+	if len(file.Symbols) > 0 {
+		scenario.Symbols = file.Symbols
+	}
+	if len(file.Intervals) > 0 {
+		scenario.Intervals = file.Intervals
+	}
+	if file.Data != "" {
+		scenario.Data = file.Data
+		if !filepath.IsAbs(file.Data) {
+			scenario.Data = filepath.Join(filepath.Dir(name), file.Data)
+		}
+	}
+	if !file.Start.IsZero() {
+		scenario.Start = file.Start
+	}
+	if !file.End.IsZero() {
+		scenario.End = file.End
+	}
+	if file.Tick != "" {
+		scenario.Tick, err = time.ParseDuration(file.Tick)
+		if err != nil {
+			return scenario, fmt.Errorf("reading scenario %s: %w", name, err)
+		}
+	}
+	if file.Addr != "" {
+		scenario.Addr = file.Addr
+	}
+	if file.Balances != nil {
+		scenario.Balances = file.Balances
+	}
+	if file.Commission != nil {
+		scenario.Commission = *file.Commission
+	}
+	if file.Faults != nil {
+		scenario.Faults = FaultProfile{Seed: file.Faults.Seed, Endpoints: map[string][]Fault{}}
+		for endpoint, faults := range file.Faults.Endpoints {
+			for _, fault := range faults {
+				parsed := Fault{Kind: fault.Kind, Probability: fault.Probability}
+				if fault.Duration != "" {
+					parsed.Duration, err = time.ParseDuration(fault.Duration)
+					if err != nil {
+						return scenario, fmt.Errorf("reading scenario %s: %w", name, err)
+					}
+				}
+				scenario.Faults.Endpoints[endpoint] = append(scenario.Faults.Endpoints[endpoint], parsed)
+			}
+		}
+	}
+	return scenario, scenario.validate()
+}
+
+func (s Scenario) validate() error {
+	if len(s.Symbols) == 0 || len(s.Intervals) == 0 {
+		return errors.New("scenario needs at least one symbol and interval")
+	}
+	for _, interval := range s.Intervals {
+		if _, ok := candleIntervalDurations[interval]; !ok {
+			return fmt.Errorf("unknown candle interval %q", interval)
+		}
+	}
+	if !s.End.After(s.Start) {
+		return errors.New("scenario has to end after it starts")
+	}
+	if s.Tick <= 0 {
+		return errors.New("scenario tick has to be positive")
+	}
+	if s.Commission.IsNegative() {
+		return errors.New("scenario commission can't be negative")
+	}
+	for endpoint, faults := range s.Faults.Endpoints {
+		for _, fault := range faults {
+			if !knownFaults[fault.Kind] {
+				return fmt.Errorf("unknown fault %q on %s", fault.Kind, endpoint)
+			}
+			if fault.Probability < 0 || fault.Probability > 1 {
+				return fmt.Errorf("fault %s on %s has to have a probability from 0 to 1", fault.Kind, endpoint)
+			}
+		}
+	}
+	return nil
+}
+
+// scenarioKey is how a symbol and interval's candles are looked up
+func scenarioKey(symbol string, interval string) string {
+	return symbol + "/" + interval
+}
+
+// loadScenarioCandles reads every candle the scenario replays, sorted by when they start
+func loadScenarioCandles(s Scenario) (map[string][]CandleResponse, error) {
+	ret := map[string][]CandleResponse{}
+	info, err := os.Stat(s.Data)
+	if err != nil {
+		return ret, err
+	}
+	if !info.IsDir() {
+		if len(s.Symbols) != 1 || len(s.Intervals) != 1 {
+			return ret, errors.New("a single candle file only works for one symbol and interval, use a directory")
+		}
+		candles, err := readCandleFile(s.Data)
+		if err != nil {
+			return ret, err
+		}
+		ret[scenarioKey(s.Symbols[0], s.Intervals[0])] = sortCandles(candles)
+		return ret, nil
+	}
+
+	for _, symbol := range s.Symbols {
+		for _, interval := range s.Intervals {
+			dir := filepath.Join(s.Data, symbol, interval)
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				return ret, fmt.Errorf("no %s %s candles: %w", symbol, interval, err)
+			}
+			candles := make([]CandleResponse, 0)
+			for _, file := range files {
+				if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+					continue
+				}
+				read, err := readCandleFile(filepath.Join(dir, file.Name()))
+				if err != nil {
+					return ret, err
+				}
+				candles = append(candles, read...)
+			}
+			if len(candles) == 0 {
+				return ret, fmt.Errorf("no %s %s candles in %s", symbol, interval, dir)
+			}
+			ret[scenarioKey(symbol, interval)] = sortCandles(candles)
+		}
+	}
+	return ret, nil
+}
+
+func sortCandles(candles []CandleResponse) []CandleResponse {
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].StartsAt.Before(candles[j].StartsAt)
+	})
+	return candles
+}
+
+// readCandleFile reads json lines or csv candles, gzipped or not
+func readCandleFile(name string) ([]CandleResponse, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	trimmed := name
+	if strings.HasSuffix(name, ".gz") {
+		zipped, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		defer zipped.Close()
+		reader = zipped
+		trimmed = strings.TrimSuffix(name, ".gz")
+	}
+
+	var candles []CandleResponse
+	switch filepath.Ext(trimmed) {
+	case ".jsonl", ".json":
+		candles, err = readCandleLines(reader)
+	case ".csv":
+		candles, err = readCandleCSV(reader)
+	default:
+		return nil, fmt.Errorf("reading %s: candle files have to be .jsonl or .csv", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return candles, nil
+}
+
+func readCandleLines(reader io.Reader) ([]CandleResponse, error) {
+	ret := make([]CandleResponse, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var candle CandleResponse
+		err := json.Unmarshal(scanner.Bytes(), &candle)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, candle)
+	}
+	return ret, scanner.Err()
+}
+
+func readCandleCSV(reader io.Reader) ([]CandleResponse, error) {
+	ret := make([]CandleResponse, 0)
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return ret, err
+	}
+	for i, row := range rows {
+		if i == 0 && row[0] == "startsAt" {
+			continue
+		}
+		if len(row) != 7 {
+			return ret, fmt.Errorf("line %d has %d columns, expected 7", i+1, len(row))
+		}
+		startsAt, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			return ret, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		values := make([]decimal.Decimal, 6)
+		for j := range values {
+			values[j], err = decimal.NewFromString(row[j+1])
+			if err != nil {
+				return ret, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+		ret = append(ret, CandleResponse{
+			StartsAt:    startsAt,
+			Open:        values[0],
+			High:        values[1],
+			Low:         values[2],
+			Close:       values[3],
+			Volume:      values[4],
+			QuoteVolume: values[5],
+		})
+	}
+	return ret, nil
+}
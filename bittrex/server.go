@@ -1,19 +1,15 @@
 package bittrex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
-var (
-	candleRequestCount = 0
-	symbol             = Symbols["Doge"]
-)
-
 func getPing(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	response := pingResponse{Response: "true"}
@@ -26,28 +22,62 @@ func getAccount(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// Get all books
-func getCandles(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	candleRequestCount++
-
-	response, err := getCandleResponse(candleRequestCount, symbol)
-
+// Start listens on the scenario's address and serves in the background until Close
+func (e *MockExchange) Start() error {
+	listener, err := net.Listen("tcp", e.scenario.Addr)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		return err
+	}
+	e.url = "http://" + listener.Addr().String()
+	e.server = &http.Server{Handler: e.Handler()}
+	go e.server.Serve(listener)
+	return nil
+}
+
+// Close stops serving and hangs up on stream connections
+func (e *MockExchange) Close() error {
+	e.stream.DropConnections()
+	if e.server == nil {
+		return nil
 	}
+	return e.server.Shutdown(context.Background())
+}
 
-	json.NewEncoder(w).Encode(response)
+// URL is where a started exchange can be reached, for WithBaseURL
+func (e *MockExchange) URL() string {
+	return e.url
+}
+
+// SocketURL is where a started exchange serves the stream, for WithSocketURL
+func (e *MockExchange) SocketURL() string {
+	return e.url + "/signalr"
 }
 
-// StartMockServer starts a new fake bitterex api
-func StartMockServer() {
+// Handler routes every endpoint the client uses
+func (e *MockExchange) Handler() http.Handler {
 	r := mux.NewRouter()
 
 	r.HandleFunc(fmt.Sprintf("/%s/ping", APIVersion), getPing).Methods("GET")
 	r.HandleFunc(fmt.Sprintf("/%s/account", APIVersion), getAccount).Methods("GET")
-	r.HandleFunc(fmt.Sprintf("/%s/markets/%s/candles/MINUTE_1/recent", APIVersion, symbol), getCandles).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/balances", APIVersion), e.getBalances).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets", APIVersion), e.getMarkets).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}", APIVersion), e.getMarketInfo).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/summary", APIVersion), e.getSummary).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/ticker", APIVersion), e.getTicker).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/candles/{interval}/recent", APIVersion), e.getCandles).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/candles/{interval}/historical/{bucket:.+}", APIVersion), e.getHistoricalCandles).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/orderbook", APIVersion), e.getOrderBook).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/markets/{symbol}/trades", APIVersion), e.getTrades).Methods("GET")
+	// open has to come before {id} so it isn't read as an order id
+	r.HandleFunc(fmt.Sprintf("/%s/orders/open", APIVersion), e.getOpenOrders).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/orders/open", APIVersion), e.deleteOpenOrders).Methods("DELETE")
+	r.HandleFunc(fmt.Sprintf("/%s/orders/closed", APIVersion), e.getClosedOrders).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/orders", APIVersion), e.postOrder).Methods("POST")
+	r.HandleFunc(fmt.Sprintf("/%s/orders/{id}", APIVersion), e.getOrder).Methods("GET")
+	r.HandleFunc(fmt.Sprintf("/%s/orders/{id}", APIVersion), e.deleteOrder).Methods("DELETE")
+	r.HandleFunc(fmt.Sprintf("/%s/executions", APIVersion), e.getExecutions).Methods("GET")
+	r.PathPrefix("/signalr/").Handler(e.stream)
+	r.Use(e.injectFaults)
 
-	log.Fatal(http.ListenAndServe(":8000", r))
+	return r
 }
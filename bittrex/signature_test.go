@@ -0,0 +1,91 @@
+package bittrex
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type signatureVector struct {
+	Name         string
+	SecretKey    string
+	Timestamp    int64
+	URI          string
+	Method       string
+	Body         string
+	SubaccountID string
+	ContentHash  string
+	Signature    string
+}
+
+func loadSignatureVectors(t *testing.T) []signatureVector {
+	content, err := ioutil.ReadFile("testdata/signatures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []signatureVector
+	err = json.Unmarshal(content, &vectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vectors
+}
+
+func TestMakeAPISignitureVectors(t *testing.T) {
+	for _, v := range loadSignatureVectors(t) {
+		hash := makeHash(v.Body)
+		if hash != v.ContentHash {
+			t.Errorf("%s: content hash was %s, expected %s", v.Name, hash, v.ContentHash)
+		}
+		got := makeAPISigniture(v.SecretKey, v.Timestamp, v.URI, v.Method, hash, v.SubaccountID)
+		if got != v.Signature {
+			t.Errorf("%s: signature was %s, expected %s", v.Name, got, v.Signature)
+		}
+	}
+}
+
+func TestOrderIsSignedOverItsBody(t *testing.T) {
+	now := time.Unix(1633333333, 333000000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Header.Get("Api-Content-Hash") != makeHash(string(body)) {
+			t.Error("Content hash does not match the body that was sent")
+		}
+		if r.Header.Get("Api-Subaccount-Id") != "mochi" {
+			t.Errorf("Subaccount header was %s, expected mochi", r.Header.Get("Api-Subaccount-Id"))
+		}
+		timestamp, _ := strconv.ParseInt(r.Header.Get("Api-Timestamp"), 10, 64)
+		if timestamp != 1633333333333 {
+			t.Errorf("Timestamp was %d, expected the injected clock", timestamp)
+		}
+		uri := "http://" + r.Host + r.URL.String()
+		expected := makeAPISigniture("bao", timestamp, uri, r.Method, makeHash(string(body)), "mochi")
+		if r.Header.Get("Api-Signature") != expected {
+			t.Error("Signature does not cover the body and subaccount")
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithBaseURL(server.URL),
+		WithCredentials("key", "bao"),
+		WithSubaccountID("mochi"),
+		WithClock(func() time.Time { return now }),
+	)
+	_, err := client.Order(context.Background(), NewOrder{MarketSymbol: "DOGE-USD", Direction: "BUY", Type: "LIMIT", Quantity: decimal.RequireFromString("100"), Limit: decimal.RequireFromString("0.05"), TimeInForce: "IMMEDIATE_OR_CANCEL"})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,554 @@
+package bittrex
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultSocketURL is the live bittrex websocket. It speaks classic SignalR.
+	DefaultSocketURL = "https://socket-v3.bittrex.com/signalr"
+	// OrderChannel streams changes to your orders
+	OrderChannel = "order"
+	// BalanceChannel streams changes to your balances
+	BalanceChannel = "balance"
+
+	socketHub      = "c3"
+	socketProtocol = "1.5"
+)
+
+var (
+	// ErrStreamSubscribe means bittrex turned down a subscription
+	ErrStreamSubscribe = errors.New("subscription failed")
+)
+
+// CandleChannel streams candles for a symbol and interval
+func CandleChannel(symbol string, interval string) string {
+	return fmt.Sprintf("candle_%s_%s", symbol, interval)
+}
+
+// TickerChannel streams the ticker for a symbol
+func TickerChannel(symbol string) string {
+	return fmt.Sprintf("ticker_%s", symbol)
+}
+
+// TradeChannel streams trades on a symbol
+func TradeChannel(symbol string) string {
+	return fmt.Sprintf("trade_%s", symbol)
+}
+
+// OrderBookChannel streams order book changes for a symbol. depth has to be one of OrderBookDepths.
+func OrderBookChannel(symbol string, depth int) string {
+	return fmt.Sprintf("orderbook_%s_%d", symbol, depth)
+}
+
+// StreamEvent is one update from a stream. Only the fields for the channel's kind of data are set.
+type StreamEvent struct {
+	Channel  string
+	Sequence int64
+	// Resync is true when the event is a REST snapshot taken after subscribing, reconnecting or missing a message.
+	// It replaces everything the channel sent before it.
+	Resync  bool
+	Candles []CandleResponse
+	Ticker  *TickerResponse
+	Trades  []TradeResponse
+	// OrderBook holds changed levels, a zero quantity means the level is gone. On a resync it is the whole book.
+	OrderBook *OrderBookResponse
+	Orders    []OrderResponse
+	Balances  []BalanceResponse
+}
+
+type candleDelta struct {
+	Sequence     int64
+	MarketSymbol string
+	Interval     string
+	Delta        CandleResponse
+}
+
+type tradeDelta struct {
+	Sequence     int64
+	MarketSymbol string
+	Deltas       []TradeResponse
+}
+
+type orderBookDelta struct {
+	MarketSymbol string
+	Depth        int
+	Sequence     int64
+	BidDeltas    []OrderBookEntry
+	AskDeltas    []OrderBookEntry
+}
+
+type orderDelta struct {
+	AccountID string
+	Sequence  int64
+	Delta     OrderResponse
+}
+
+type balanceDelta struct {
+	AccountID string
+	Sequence  int64
+	Delta     BalanceResponse
+}
+
+// socketMessage is a classic SignalR frame. Server pushes come in M, answers to invocations in R and I.
+type socketMessage struct {
+	C string          `json:"C,omitempty"`
+	M []socketPush    `json:"M,omitempty"`
+	R json.RawMessage `json:"R,omitempty"`
+	I string          `json:"I,omitempty"`
+	E string          `json:"E,omitempty"`
+}
+
+type socketPush struct {
+	H string   `json:"H"`
+	M string   `json:"M"`
+	A []string `json:"A"`
+}
+
+type socketInvocation struct {
+	H string        `json:"H"`
+	M string        `json:"M"`
+	A []interface{} `json:"A"`
+	I int           `json:"I"`
+}
+
+type socketResult struct {
+	Success   bool
+	ErrorCode string
+}
+
+// Stream is a live subscription to bittrex websocket channels. It reconnects on its own until ctx is done.
+type Stream struct {
+	client    *Client
+	channels  []string
+	events    chan StreamEvent
+	sequences map[string]int64
+	nextID    int
+	mu        sync.Mutex
+	err       error
+}
+
+// Subscribe opens a stream of the given channels. Order and balance channels need credentials.
+// Every channel is resynced from REST before its first socket message.
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (*Stream, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("%w: no channels", ErrStreamSubscribe)
+	}
+	stream := &Stream{
+		client:    c,
+		channels:  channels,
+		events:    make(chan StreamEvent, 100),
+		sequences: map[string]int64{},
+	}
+	conn, pending, err := stream.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go stream.run(ctx, conn, pending)
+	return stream, nil
+}
+
+// Events are the updates from the stream. It is closed when the stream stops, check Err for why.
+func (s *Stream) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Err is why the stream stopped, nil while it is still running
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *Stream) run(ctx context.Context, conn *websocket.Conn, pending []socketMessage) {
+	defer close(s.events)
+	for {
+		// Anything could have happened while we weren't listening
+		err := s.resyncAll(ctx)
+		if err == nil {
+			err = s.listen(ctx, conn, pending)
+		}
+		conn.Close()
+		if ctx.Err() != nil {
+			s.stop(ctx.Err())
+			return
+		}
+
+		conn, pending, err = s.reconnect(ctx)
+		if err != nil {
+			s.stop(err)
+			return
+		}
+	}
+}
+
+func (s *Stream) reconnect(ctx context.Context) (*websocket.Conn, []socketMessage, error) {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(s.client.retry.backoff(attempt)):
+		}
+		conn, pending, err := s.connect(ctx)
+		if err == nil {
+			return conn, pending, nil
+		}
+		// Trying again won't fix a bad key or a channel that doesn't exist
+		if errors.Is(err, ErrBadCredentials) || errors.Is(err, ErrStreamSubscribe) {
+			return nil, nil, err
+		}
+	}
+}
+
+// listen reads from the socket until it fails or ctx is done
+func (s *Stream) listen(ctx context.Context, conn *websocket.Conn, pending []socketMessage) error {
+	for _, message := range pending {
+		if err := s.handleMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var message socketMessage
+		err := conn.ReadJSON(&message)
+		if err != nil {
+			return err
+		}
+		if err := s.handleMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Stream) handleMessage(ctx context.Context, message socketMessage) error {
+	for _, push := range message.M {
+		if !strings.EqualFold(push.H, socketHub) || len(push.A) == 0 {
+			continue
+		}
+		payload, err := decodeSocketPayload(push.A[0])
+		if err != nil {
+			return err
+		}
+		err = s.handlePush(ctx, push.M, payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stream) handlePush(ctx context.Context, method string, payload []byte) error {
+	switch method {
+	case "candle":
+		var delta candleDelta
+		if err := json.Unmarshal(payload, &delta); err != nil {
+			return err
+		}
+		channel := CandleChannel(delta.MarketSymbol, delta.Interval)
+		return s.sequenced(ctx, channel, delta.Sequence, StreamEvent{Candles: []CandleResponse{delta.Delta}})
+	case "ticker":
+		var ticker TickerResponse
+		if err := json.Unmarshal(payload, &ticker); err != nil {
+			return err
+		}
+		return s.emit(ctx, StreamEvent{Channel: TickerChannel(ticker.Symbol), Ticker: &ticker})
+	case "trade":
+		var delta tradeDelta
+		if err := json.Unmarshal(payload, &delta); err != nil {
+			return err
+		}
+		return s.sequenced(ctx, TradeChannel(delta.MarketSymbol), delta.Sequence, StreamEvent{Trades: delta.Deltas})
+	case "orderBook":
+		var delta orderBookDelta
+		if err := json.Unmarshal(payload, &delta); err != nil {
+			return err
+		}
+		book := OrderBookResponse{Bid: delta.BidDeltas, Ask: delta.AskDeltas, Sequence: delta.Sequence}
+		return s.sequenced(ctx, OrderBookChannel(delta.MarketSymbol, delta.Depth), delta.Sequence, StreamEvent{OrderBook: &book})
+	case "order":
+		var delta orderDelta
+		if err := json.Unmarshal(payload, &delta); err != nil {
+			return err
+		}
+		return s.sequenced(ctx, OrderChannel, delta.Sequence, StreamEvent{Orders: []OrderResponse{delta.Delta}})
+	case "balance":
+		var delta balanceDelta
+		if err := json.Unmarshal(payload, &delta); err != nil {
+			return err
+		}
+		return s.sequenced(ctx, BalanceChannel, delta.Sequence, StreamEvent{Balances: []BalanceResponse{delta.Delta}})
+	}
+	// Heartbeats and anything newer than this client
+	return nil
+}
+
+// sequenced drops messages we already have, and resyncs the channel if one went missing
+func (s *Stream) sequenced(ctx context.Context, channel string, sequence int64, event StreamEvent) error {
+	last, known := s.sequences[channel]
+	if known && sequence <= last {
+		return nil
+	}
+	if known && sequence > last+1 {
+		err := s.resync(ctx, channel)
+		if err != nil {
+			return err
+		}
+		// The snapshot is at least as new as this message
+		if s.sequences[channel] < sequence {
+			s.sequences[channel] = sequence
+		}
+		return nil
+	}
+	s.sequences[channel] = sequence
+	event.Channel = channel
+	event.Sequence = sequence
+	return s.emit(ctx, event)
+}
+
+func (s *Stream) emit(ctx context.Context, event StreamEvent) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.events <- event:
+		return nil
+	}
+}
+
+func (s *Stream) resyncAll(ctx context.Context) error {
+	for _, channel := range s.channels {
+		err := s.resync(ctx, channel)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resync replaces a channel with a REST snapshot. Channels that don't say what sequence they are at take
+// the next message they get as a fresh start.
+func (s *Stream) resync(ctx context.Context, channel string) error {
+	delete(s.sequences, channel)
+	event := StreamEvent{Channel: channel, Resync: true}
+	// Candle intervals have underscores of their own, like MINUTE_1
+	parts := strings.SplitN(channel, "_", 3)
+	switch {
+	case parts[0] == "candle" && len(parts) == 3:
+		candles, err := s.client.GetCandles(ctx, parts[1], parts[2])
+		if err != nil {
+			return err
+		}
+		event.Candles = candles
+	case parts[0] == "trade" && len(parts) == 2:
+		trades, err := s.client.GetRecentTrades(ctx, parts[1])
+		if err != nil {
+			return err
+		}
+		event.Trades = trades
+	case parts[0] == "orderbook" && len(parts) == 3:
+		depth, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return err
+		}
+		book, err := s.client.GetOrderBook(ctx, parts[1], depth)
+		if err != nil {
+			return err
+		}
+		s.sequences[channel] = book.Sequence
+		event.Sequence = book.Sequence
+		event.OrderBook = &book
+	case channel == OrderChannel:
+		orders, err := s.client.ListOpenOrders(ctx, "")
+		if err != nil {
+			return err
+		}
+		event.Orders = orders
+	case channel == BalanceChannel:
+		balances, err := s.client.GetBalances(ctx)
+		if err != nil {
+			return err
+		}
+		event.Balances = balances
+	default:
+		// Tickers are the latest value every time, there is nothing to catch up on
+		return nil
+	}
+	return s.emit(ctx, event)
+}
+
+// connect runs the SignalR handshake, then authenticates if needed and subscribes. Pushes that show up
+// before the subscription is confirmed are handed back so they aren't lost.
+func (s *Stream) connect(ctx context.Context) (*websocket.Conn, []socketMessage, error) {
+	connectionData := fmt.Sprintf(`[{"name":"%s"}]`, socketHub)
+	query := url.Values{}
+	query.Set("clientProtocol", socketProtocol)
+	query.Set("connectionData", connectionData)
+
+	var negotiated struct {
+		ConnectionToken string
+	}
+	resp, err := s.client.get(ctx, fmt.Sprintf("%s/negotiate?%s", s.client.socketURL, query.Encode()), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = readJSON(resp, &negotiated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query.Set("transport", "webSockets")
+	query.Set("connectionToken", negotiated.ConnectionToken)
+	socketURL := strings.Replace(s.client.socketURL, "http", "ws", 1)
+	dialer := websocket.Dialer{HandshakeTimeout: s.client.httpClient.Timeout, Proxy: http.ProxyFromEnvironment}
+	conn, _, err := dialer.DialContext(ctx, fmt.Sprintf("%s/connect?%s", socketURL, query.Encode()), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err = s.client.get(ctx, fmt.Sprintf("%s/start?%s", s.client.socketURL, query.Encode()), false)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	resp.Body.Close()
+
+	pending := make([]socketMessage, 0)
+	if s.needsAuth() {
+		timestamp := s.client.makeTimestamp()
+		randomContent, err := newClientOrderID()
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		signature := makeSocketSigniture(s.client.auth.secretKey, timestamp, randomContent)
+		var result socketResult
+		pending, err = s.invoke(conn, "Authenticate", []interface{}{s.client.auth.apiKey, timestamp, randomContent, signature}, &result, pending)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if !result.Success {
+			conn.Close()
+			return nil, nil, &APIError{StatusCode: http.StatusUnauthorized, Code: result.ErrorCode, URL: s.client.socketURL}
+		}
+	}
+
+	var results []socketResult
+	pending, err = s.invoke(conn, "Subscribe", []interface{}{s.channels}, &results, pending)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	for i, result := range results {
+		if !result.Success && i < len(s.channels) {
+			conn.Close()
+			return nil, nil, fmt.Errorf("%w: %s %s", ErrStreamSubscribe, s.channels[i], result.ErrorCode)
+		}
+	}
+
+	return conn, pending, nil
+}
+
+// invoke calls a hub method and waits for its answer, holding on to any pushes that arrive first
+func (s *Stream) invoke(conn *websocket.Conn, method string, args []interface{}, result interface{}, pending []socketMessage) ([]socketMessage, error) {
+	s.nextID++
+	id := s.nextID
+	err := conn.WriteJSON(socketInvocation{H: socketHub, M: method, A: args, I: id})
+	if err != nil {
+		return pending, err
+	}
+	conn.SetReadDeadline(time.Now().Add(s.client.httpClient.Timeout))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		var message socketMessage
+		err := conn.ReadJSON(&message)
+		if err != nil {
+			return pending, err
+		}
+		if message.I != strconv.Itoa(id) {
+			pending = append(pending, message)
+			continue
+		}
+		if message.E != "" {
+			return pending, fmt.Errorf("%s failed: %s", method, message.E)
+		}
+		return pending, json.Unmarshal(message.R, result)
+	}
+}
+
+func (s *Stream) needsAuth() bool {
+	for _, channel := range s.channels {
+		if channel == OrderChannel || channel == BalanceChannel {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSocketPayload undoes what bittrex does to every push: json, raw deflate, then base64
+func decodeSocketPayload(payload string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+func encodeSocketPayload(payload []byte) (string, error) {
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	_, err = writer.Write(payload)
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(compressed.Bytes()), nil
+}
+
+// makeSocketSigniture signs a websocket Authenticate call https://bittrex.github.io/api/v3#topic-Authenticating
+func makeSocketSigniture(secretKey string, timestamp int64, randomContent string) string {
+	hasher := hmac.New(sha512.New, []byte(secretKey))
+	hasher.Write([]byte(fmt.Sprintf("%d%s", timestamp, randomContent)))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
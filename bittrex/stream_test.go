@@ -0,0 +1,200 @@
+package bittrex
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newStreamTestServer(t *testing.T) (*httptest.Server, *MockStreamServer) {
+	exchange := newTestExchange(t)
+	return httptest.NewServer(exchange.Handler()), exchange.Stream()
+}
+
+func newStreamTestClient(server *httptest.Server) *Client {
+	return NewClient(
+		WithBaseURL(server.URL),
+		WithSocketURL(server.URL+"/signalr"),
+		WithCredentials("key", "secret"),
+		WithRateLimiter(nil),
+		WithRetryPolicy(fastRetries),
+	)
+}
+
+func nextEvent(t *testing.T, stream *Stream) StreamEvent {
+	select {
+	case event, ok := <-stream.Events():
+		if !ok {
+			t.Fatalf("Stream stopped: %v", stream.Err())
+		}
+		return event
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return StreamEvent{}
+}
+
+func TestStreamResyncsOnGaps(t *testing.T) {
+	server, mock := newStreamTestServer(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	channel := OrderBookChannel("DOGE-USD", 25)
+	stream, err := newStreamTestClient(server).Subscribe(ctx, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := nextEvent(t, stream)
+	if !event.Resync || event.Sequence != 1 || len(event.OrderBook.Bid) != 25 {
+		t.Fatalf("First event should be the REST snapshot, was %+v", event)
+	}
+
+	level := []OrderBookEntry{{Quantity: decimal.Zero, Rate: decimal.RequireFromString("0.0499")}}
+	mock.PublishOrderBook("DOGE-USD", 25, level, nil)
+	event = nextEvent(t, stream)
+	if event.Resync || event.Sequence != 2 || len(event.OrderBook.Bid) != 1 {
+		t.Fatalf("Expected the delta at sequence 2, was %+v", event)
+	}
+
+	mock.SkipSequence(channel)
+	mock.PublishOrderBook("DOGE-USD", 25, level, nil)
+	event = nextEvent(t, stream)
+	if !event.Resync || len(event.OrderBook.Bid) != 25 {
+		t.Fatalf("Expected a resync after the gap, was %+v", event)
+	}
+
+	mock.PublishOrderBook("DOGE-USD", 25, nil, level)
+	event = nextEvent(t, stream)
+	if event.Resync || event.Sequence != 5 || len(event.OrderBook.Ask) != 1 {
+		t.Fatalf("Expected deltas to pick back up at sequence 5, was %+v", event)
+	}
+}
+
+func TestStreamResyncsCandlesOnGaps(t *testing.T) {
+	server, mock := newStreamTestServer(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Polling the scenario's lead symbol moves the mock's clock on and publishes candles of its own, so this
+	// sticks to the other one
+	interval := CandleIntervals["1min"]
+	channel := CandleChannel("BTC-USD", interval)
+	stream, err := newStreamTestClient(server).Subscribe(ctx, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := nextEvent(t, stream)
+	if !event.Resync || event.Channel != channel || len(event.Candles) != 24*60 {
+		t.Fatalf("First event should be the REST snapshot, was %+v", event)
+	}
+
+	candle := CandleResponse{StartsAt: testScenarioStart, Close: decimal.NewFromInt(9000)}
+	mock.PublishCandle("BTC-USD", interval, candle)
+	event = nextEvent(t, stream)
+	if event.Resync || event.Sequence != 1 || len(event.Candles) != 1 {
+		t.Fatalf("Expected the candle at sequence 1, was %+v", event)
+	}
+
+	mock.SkipSequence(channel)
+	mock.PublishCandle("BTC-USD", interval, candle)
+	event = nextEvent(t, stream)
+	if !event.Resync || event.Channel != channel || len(event.Candles) != 24*60 {
+		t.Fatalf("Expected a resync after the gap, was %+v", event)
+	}
+
+	mock.PublishCandle("BTC-USD", interval, candle)
+	event = nextEvent(t, stream)
+	if event.Resync || event.Sequence != 4 || len(event.Candles) != 1 {
+		t.Fatalf("Expected candles to pick back up at sequence 4, was %+v", event)
+	}
+}
+
+func TestStreamReconnects(t *testing.T) {
+	server, mock := newStreamTestServer(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	channel := TradeChannel("DOGE-USD")
+	stream, err := newStreamTestClient(server).Subscribe(ctx, channel, TickerChannel("DOGE-USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := nextEvent(t, stream)
+	if !event.Resync || len(event.Trades) != 10 {
+		t.Fatalf("First event should be the REST snapshot, was %+v", event)
+	}
+
+	mock.DropConnections()
+	event = nextEvent(t, stream)
+	if !event.Resync || event.Channel != channel {
+		t.Fatalf("Expected a resync after reconnecting, was %+v", event)
+	}
+	if mock.Subscribers(channel) != 1 {
+		t.Fatalf("Expected to be subscribed again, had %d subscribers", mock.Subscribers(channel))
+	}
+
+	mock.PublishTicker(TickerResponse{Symbol: "DOGE-USD", LastTradeRate: decimal.RequireFromString("0.05")})
+	event = nextEvent(t, stream)
+	if event.Ticker == nil || event.Ticker.LastTradeRate.String() != "0.05" {
+		t.Fatalf("Expected the ticker, was %+v", event)
+	}
+
+	cancel()
+	for range stream.Events() {
+	}
+	if stream.Err() != context.Canceled {
+		t.Errorf("Stream stopped with %v, expected it to be canceled", stream.Err())
+	}
+}
+
+func TestStreamAuthenticatedChannels(t *testing.T) {
+	server, mock := newStreamTestServer(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := newStreamTestClient(server).Subscribe(ctx, OrderChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := nextEvent(t, stream)
+	if !event.Resync || event.Channel != OrderChannel {
+		t.Fatalf("First event should be the open orders, was %+v", event)
+	}
+
+	mock.PublishOrder(OrderResponse{ID: "mochi", Status: "CLOSED"})
+	event = nextEvent(t, stream)
+	if len(event.Orders) != 1 || event.Orders[0].ID != "mochi" || event.Sequence != 1 {
+		t.Fatalf("Expected the order update, was %+v", event)
+	}
+}
+
+func TestStreamRejectsUnknownChannels(t *testing.T) {
+	server, _ := newStreamTestServer(t)
+	defer server.Close()
+
+	_, err := newStreamTestClient(server).Subscribe(context.Background(), "mochi_and_bao")
+	if !errors.Is(err, ErrStreamSubscribe) {
+		t.Errorf("Was %v, expected the subscription to fail", err)
+	}
+}
+
+func TestSocketPayloadRoundTrip(t *testing.T) {
+	encoded, err := encodeSocketPayload([]byte(`{"sequence":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeSocketPayload(encoded)
+	if err != nil || string(decoded) != `{"sequence":1}` {
+		t.Errorf("Decoded %s, %v", decoded, err)
+	}
+}
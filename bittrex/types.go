@@ -1,5 +1,11 @@
 package bittrex
 
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 // Auth is the type for bittrex creds
 type Auth struct {
 	apiKey    string
@@ -13,31 +19,31 @@ type pingResponse struct {
 // MarketResponse is a market response
 type MarketResponse struct {
 	Symbol        string
-	High          string
-	Low           string
-	Volume        string
-	QuoteVolume   string
-	PercentChange string
-	UpdatedAt     string
+	High          decimal.Decimal
+	Low           decimal.Decimal
+	Volume        decimal.Decimal
+	QuoteVolume   decimal.Decimal
+	PercentChange decimal.Decimal
+	UpdatedAt     time.Time
 }
 
 // TickerResponse is a ticker response
 type TickerResponse struct {
 	Symbol        string
-	LastTradeRate string
-	BidRate       string
-	AskRate       string
+	LastTradeRate decimal.Decimal
+	BidRate       decimal.Decimal
+	AskRate       decimal.Decimal
 }
 
 // CandleResponse is a candle response
 type CandleResponse struct {
-	StartsAt    string
-	Open        string
-	High        string
-	Low         string
-	Close       string
-	Volume      string
-	QuoteVolume string
+	StartsAt    time.Time
+	Open        decimal.Decimal
+	High        decimal.Decimal
+	Low         decimal.Decimal
+	Close       decimal.Decimal
+	Volume      decimal.Decimal
+	QuoteVolume decimal.Decimal
 }
 
 // AccountResponse is an account response
@@ -49,22 +55,23 @@ type AccountResponse struct {
 // BalanceResponse is one account ballance
 type BalanceResponse struct {
 	CurrencySymbol string
-	Total          int
-	Available      int
-	UpdatedAt      string
+	Total          decimal.Decimal
+	Available      decimal.Decimal
+	UpdatedAt      time.Time
 }
 
 // BalancesResponce is all account ballances
 type BalancesResponce []BalanceResponse
 
-// NewOrder is the request body of a new order
+// NewOrder is the request body of a new order. ClientOrderID is what lets us find an order again if we lose track of it.
 type NewOrder struct {
-	MarketSymbol string
-	Direction    string
-	Type         string
-	Quantity     float64
-	Limit        float64
-	TimeInForce  string
+	MarketSymbol  string
+	Direction     string
+	Type          string
+	Quantity      decimal.Decimal
+	Limit         decimal.Decimal
+	TimeInForce   string
+	ClientOrderID string
 }
 
 // OrderResponse is the response from a new order
@@ -73,20 +80,109 @@ type OrderResponse struct {
 	MarketSymbol  string
 	Direction     string
 	Type          string
-	Quantity      string
-	Limit         string
-	Ceiling       string
+	Quantity      decimal.Decimal
+	Limit         decimal.Decimal
+	Ceiling       decimal.Decimal
 	TimeInForce   string
 	ClientOrderID string
-	FillQuantity  string
-	Commission    string
-	Proceeds      string
+	FillQuantity  decimal.Decimal
+	Commission    decimal.Decimal
+	Proceeds      decimal.Decimal
 	Status        string
-	CreatedAt     string
-	UpdatedAt     string
-	ClosedAt      string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      time.Time
 	OrderToCancel struct {
 		Type string
 		ID   string
 	}
 }
+
+// BulkCancelResult is the outcome of canceling one order out of many
+type BulkCancelResult struct {
+	ID         string
+	StatusCode string
+	Result     OrderResponse
+}
+
+// ExecutionResponse is one fill of an order
+type ExecutionResponse struct {
+	ID           string
+	MarketSymbol string
+	ExecutedAt   time.Time
+	Quantity     decimal.Decimal
+	Rate         decimal.Decimal
+	OrderID      string
+	Commission   decimal.Decimal
+	IsTaker      bool
+}
+
+// Paging narrows down a closed order or execution listing. Zero values are left out of the request.
+type Paging struct {
+	NextPageToken     string
+	PreviousPageToken string
+	PageSize          int
+	StartDate         time.Time
+	EndDate           time.Time
+}
+
+// OrderBookEntry is one price level of an order book
+type OrderBookEntry struct {
+	Quantity decimal.Decimal
+	Rate     decimal.Decimal
+}
+
+// OrderBookResponse is an order book response. Bid is sorted best (highest) first and Ask best (lowest) first.
+type OrderBookResponse struct {
+	Bid []OrderBookEntry
+	Ask []OrderBookEntry
+	// Sequence is the order book version bittrex sends in the Sequence header
+	Sequence int64 `json:"-"`
+}
+
+// Spread is the gap between the best ask and the best bid, zero if either side is empty
+func (book OrderBookResponse) Spread() decimal.Decimal {
+	if len(book.Bid) == 0 || len(book.Ask) == 0 {
+		return decimal.Zero
+	}
+	return book.Ask[0].Rate.Sub(book.Bid[0].Rate)
+}
+
+// BidDepth is the total quantity on the bid side
+func (book OrderBookResponse) BidDepth() decimal.Decimal {
+	return sumQuantity(book.Bid)
+}
+
+// AskDepth is the total quantity on the ask side
+func (book OrderBookResponse) AskDepth() decimal.Decimal {
+	return sumQuantity(book.Ask)
+}
+
+func sumQuantity(entries []OrderBookEntry) decimal.Decimal {
+	total := decimal.Zero
+	for _, entry := range entries {
+		total = total.Add(entry.Quantity)
+	}
+	return total
+}
+
+// TradeResponse is one trade on a market
+type TradeResponse struct {
+	ID         string
+	ExecutedAt time.Time
+	Quantity   decimal.Decimal
+	Rate       decimal.Decimal
+	TakerSide  string
+}
+
+// MarketInfoResponse is what a market trades and the limits on its orders
+type MarketInfoResponse struct {
+	Symbol              string
+	BaseCurrencySymbol  string
+	QuoteCurrencySymbol string
+	MinTradeSize        decimal.Decimal
+	// Precision is how many decimal places a limit can have
+	Precision int32
+	Status    string
+	CreatedAt time.Time
+}
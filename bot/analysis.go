@@ -1,7 +1,8 @@
 package bot
 
 import (
-	"cryptofu/bittrex"
+	"cryptofu/exchange"
+	"fmt"
 
 	"github.com/shopspring/decimal"
 )
@@ -11,22 +12,64 @@ var (
 	two   = decimal.NewFromInt(2)
 	three = decimal.NewFromInt(3)
 
-	p12Smoothing = CalculateEMASmoothing(12)
-	p26Smoothing = CalculateEMASmoothing(26)
+	// DefaultPeriods are the textbook MACD periods, and a TEMA of 1 that follows the close the way the bot always has
+	DefaultPeriods = Periods{
+		TEMA:     1,
+		MACDFast: 12,
+		MACDSlow: 26,
+		Signal:   9,
+		ATR:      14,
+	}
 )
 
+// Periods are how many candles each indicator looks back over
+type Periods struct {
+	TEMA     int `json:"tema"`
+	MACDFast int `json:"macdFast"`
+	MACDSlow int `json:"macdSlow"`
+	Signal   int `json:"signal"`
+	ATR      int `json:"atr"`
+}
+
+// withDefaults fills in any period left at zero from DefaultPeriods
+func (p Periods) withDefaults() Periods {
+	if p.TEMA == 0 {
+		p.TEMA = DefaultPeriods.TEMA
+	}
+	if p.MACDFast == 0 {
+		p.MACDFast = DefaultPeriods.MACDFast
+	}
+	if p.MACDSlow == 0 {
+		p.MACDSlow = DefaultPeriods.MACDSlow
+	}
+	if p.Signal == 0 {
+		p.Signal = DefaultPeriods.Signal
+	}
+	if p.ATR == 0 {
+		p.ATR = DefaultPeriods.ATR
+	}
+	return p
+}
+
+// validate makes sure the periods can be calculated with
+func (p Periods) validate() error {
+	if p.TEMA < 1 || p.MACDFast < 1 || p.MACDSlow < 1 || p.Signal < 1 || p.ATR < 1 {
+		return fmt.Errorf("%w: every period has to be at least 1, got %+v", ErrInvalidPeriods, p)
+	}
+	if p.MACDFast >= p.MACDSlow {
+		return fmt.Errorf("%w: the fast MACD period %d has to be shorter than the slow one %d", ErrInvalidPeriods, p.MACDFast, p.MACDSlow)
+	}
+	return nil
+}
+
 // CandlesToSMA calculates SMA from a slice of candles
-func CandlesToSMA(candles []bittrex.CandleResponse) (decimal.Decimal, error) {
+func CandlesToSMA(candles []exchange.Candle) decimal.Decimal {
 	sma := decimal.NewFromInt(0)
 	for i := 0; i < len(candles); i++ {
-		num, err := decimal.NewFromString(candles[i].Close)
-		if err != nil {
-			return sma, err
-		}
-		sma = sma.Add(num)
+		sma = sma.Add(candles[i].Close)
 	}
 	sma = sma.Div(decimal.NewFromInt(int64(len(candles))))
-	return sma, nil
+	return sma
 }
 
 // DecimalsToSMA calculates SMA from a slice of decimal.Decimals
@@ -58,48 +101,43 @@ func CalculateTEMA(forThis decimal.Decimal, basedOn decimal.Decimal, smoothing d
 }
 
 // CandleToEMA converts a candle value to an EMA value
-func CandleToEMA(candle bittrex.CandleResponse, lastVal decimal.Decimal, smoothing decimal.Decimal) (decimal.Decimal, error) {
-	bid, err := decimal.NewFromString(candle.Close)
-	if err != nil {
-		return decimal.Zero, err
-	}
-	return CalculateEMA(bid, lastVal, smoothing), nil
+func CandleToEMA(candle exchange.Candle, lastVal decimal.Decimal, smoothing decimal.Decimal) decimal.Decimal {
+	return CalculateEMA(candle.Close, lastVal, smoothing)
 }
 
 // CandleToTEMA converts a candle value into a TEMA value
-func CandleToTEMA(candle bittrex.CandleResponse, lastVal decimal.Decimal, smoothing decimal.Decimal) (decimal.Decimal, error) {
-	bid, err := decimal.NewFromString(candle.Close)
-	if err != nil {
-		return decimal.Zero, err
-	}
-	return CalculateTEMA(bid, lastVal, smoothing), nil
+func CandleToTEMA(candle exchange.Candle, lastVal decimal.Decimal, smoothing decimal.Decimal) decimal.Decimal {
+	return CalculateTEMA(candle.Close, lastVal, smoothing)
 }
 
-// CalculateMACD calculates a macd value from a slice of tickers
-func CalculateMACD(forThis decimal.Decimal, fromThese []bittrex.CandleResponse) (decimal.Decimal, error) {
+// CalculateMACD calculates a macd value from a slice of tickers with the usual 12 and 26 periods
+func CalculateMACD(forThis decimal.Decimal, fromThese []exchange.Candle) (decimal.Decimal, error) {
+	return CalculateMACDPeriods(forThis, fromThese, DefaultPeriods.MACDFast, DefaultPeriods.MACDSlow)
+}
+
+// CalculateMACDPeriods calculates a macd value from a slice of tickers with a fast and slow period of your choosing
+func CalculateMACDPeriods(forThis decimal.Decimal, fromThese []exchange.Candle, fast int, slow int) (decimal.Decimal, error) {
 	// check data
-	if len(fromThese) < 26 {
+	if len(fromThese) < slow {
 		return decimal.Zero, ErrCalcMACDNotEnoughInfo
 	}
-	// 12 period ema
-	sma1, err := CandlesToSMA(fromThese[len(fromThese)-12:])
-	if err != nil {
-		return decimal.Zero, err
-	}
-	ema12P := CalculateEMA(forThis, sma1, p12Smoothing)
-	// 26 period ema
-	sma2, err := CandlesToSMA(fromThese[len(fromThese)-26:])
-	if err != nil {
-		return decimal.Zero, err
-	}
-	ema26P := CalculateEMA(forThis, sma2, p26Smoothing)
+	// fast period ema
+	sma1 := CandlesToSMA(fromThese[len(fromThese)-fast:])
+	emaFast := CalculateEMA(forThis, sma1, CalculateEMASmoothing(fast))
+	// slow period ema
+	sma2 := CandlesToSMA(fromThese[len(fromThese)-slow:])
+	emaSlow := CalculateEMA(forThis, sma2, CalculateEMASmoothing(slow))
 	// return the result of the MACD formula with these values
-	return ema12P.Sub(ema26P), nil
+	return emaFast.Sub(emaSlow), nil
 }
 
-// CalculateSignalLine calculates a signal line
+// CalculateSignalLine calculates a signal line with the usual 9 period EMA
 func CalculateSignalLine(fromThese []decimal.Decimal, lastVal decimal.Decimal) (decimal.Decimal, error) {
-	signalPeriod := 9 // MACD Signal line uses 9 day EMA
+	return CalculateSignalLinePeriod(fromThese, lastVal, DefaultPeriods.Signal)
+}
+
+// CalculateSignalLinePeriod calculates a signal line over signalPeriod MACD values
+func CalculateSignalLinePeriod(fromThese []decimal.Decimal, lastVal decimal.Decimal, signalPeriod int) (decimal.Decimal, error) {
 	// Check data
 	if len(fromThese) < signalPeriod {
 		return decimal.Zero, ErrCalcSignalNotEnoughInfo
@@ -111,6 +149,22 @@ func CalculateSignalLine(fromThese []decimal.Decimal, lastVal decimal.Decimal) (
 	}
 }
 
+// CandlesToATR calculates the average true range of the last period candles. It needs one more candle than
+// the period for the first candle's previous close.
+func CandlesToATR(candles []exchange.Candle, period int) (decimal.Decimal, error) {
+	if period < 1 || len(candles) < period+1 {
+		return decimal.Zero, ErrCalcATRNotEnoughInfo
+	}
+	total := decimal.Zero
+	for i := len(candles) - period; i < len(candles); i++ {
+		previousClose := candles[i-1].Close
+		trueRange := candles[i].High.Sub(candles[i].Low)
+		trueRange = decimal.Max(trueRange, candles[i].High.Sub(previousClose).Abs(), candles[i].Low.Sub(previousClose).Abs())
+		total = total.Add(trueRange)
+	}
+	return total.Div(decimal.NewFromInt(int64(period))), nil
+}
+
 // CalculateHistogram is here so I don't forget that the equation is this simple
 func CalculateHistogram(macd decimal.Decimal, signal decimal.Decimal) decimal.Decimal {
 	return macd.Sub(signal)
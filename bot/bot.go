@@ -1,9 +1,14 @@
 package bot
 
 import (
+	"context"
 	"cryptofu/bittrex"
+	"cryptofu/candlestore"
+	"cryptofu/exchange"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -14,14 +19,13 @@ var (
 	logger = func() *zap.SugaredLogger {
 		logger, err := zap.NewDevelopment()
 		if err != nil {
-			log.Fatal("💩 Error getting logger set up?")
+			// Going without logs beats taking down whatever embeds the bot
+			logger = zap.NewNop()
 		}
 		defer logger.Sync()
 		sugar := logger.Sugar()
 		return sugar
 	}()
-	// SelfDestruct is a channel the bot can use to kill the go process at any time
-	SelfDestruct = make(chan bool)
 	// Modes are accepted bot modes
 	Modes = map[string]string{
 		"Testing":    "testing",
@@ -29,147 +33,328 @@ var (
 		"Paper":      "paper",
 	}
 	intervalToSleepSeconds = map[string]int{
-		bittrex.CandleIntervals["1min"]: 70,
-		bittrex.CandleIntervals["5min"]: 300,
-	}
-	intervalToPeriod = map[string]int{
-		bittrex.CandleIntervals["1min"]: 1,
-		bittrex.CandleIntervals["5min"]: 1,
+		exchange.Interval1Min:  70,
+		exchange.Interval5Min:  300,
+		exchange.Interval1Hour: 3600,
+		exchange.Interval1Day:  86400,
 	}
 )
 
 // Bot is the main trading bot
 type Bot struct {
+	// Name tells bots apart in logs and state files, and is the symbol unless set
+	Name             string
 	Mode             string
 	Symbol           string
 	Interval         string
-	Period           int
-	trailLag         decimal.Decimal
-	candleHistory    []bittrex.CandleResponse
+	client           exchange.Exchange
+	mock             *bittrex.MockExchange
+	rotationTimeout  time.Duration
+	throttleBackoff  time.Duration
+	strategy         Strategy
+	periods          Periods
+	candleHistory    []exchange.Candle
 	temaHistory      []decimal.Decimal
 	macdHistory      []decimal.Decimal
 	signalHistory    []decimal.Decimal
-	orderHistory     []bittrex.OrderResponse
+	orderHistory     []exchange.Order
 	maxHistoryLength int
-	currentOrder     bittrex.OrderResponse
+	currentOrder     exchange.Order
+	uncertainOrders  []exchange.OrderRequest
 	currentTrail     decimal.Decimal
+	buyHistory       []exchange.Candle
+	sellHistory      []exchange.Candle
+	log              *zap.SugaredLogger
+}
+
+// Option configures a Bot
+type Option func(*Bot)
+
+// WithName names the bot. Bots trading the same symbol need different names to keep their state apart.
+func WithName(name string) Option {
+	return func(b *Bot) {
+		b.Name = name
+	}
+}
+
+// WithInterval trades on candles of interval instead of a minute
+func WithInterval(interval string) Option {
+	return func(b *Bot) {
+		b.Interval = interval
+	}
+}
+
+// WithPeriods changes how many candles the indicators look back over. Periods left at zero keep their defaults.
+func WithPeriods(periods Periods) Option {
+	return func(b *Bot) {
+		b.periods = periods.withDefaults()
+	}
 }
 
-// NewBot makes a new trading bot with very sensible default values
-func NewBot(mode string, symbol string) *Bot {
+// NewBot makes a new trading bot with very sensible default values and sets it up. The bot trades on venue,
+// whichever exchange that is, the way strategy decides once Run is called. Bots share nothing but venue, so
+// several can trade side by side in one process as long as venue is safe to share.
+func NewBot(ctx context.Context, mode string, symbol string, venue exchange.Exchange, strategy Strategy, options ...Option) (*Bot, error) {
 	babyBot := Bot{
 		Mode:             mode,
 		Symbol:           symbol,
-		Interval:         bittrex.CandleIntervals["1min"],
-		Period:           intervalToPeriod[bittrex.CandleIntervals["1min"]],
-		trailLag:         decimal.NewFromInt(5),
-		candleHistory:    make([]bittrex.CandleResponse, 0),
+		Interval:         exchange.Interval1Min,
+		client:           venue,
+		rotationTimeout:  time.Second * 60,
+		throttleBackoff:  time.Second * 60,
+		strategy:         strategy,
+		periods:          DefaultPeriods,
+		candleHistory:    make([]exchange.Candle, 0),
 		temaHistory:      make([]decimal.Decimal, 0),
 		macdHistory:      make([]decimal.Decimal, 0),
 		signalHistory:    []decimal.Decimal{decimal.Zero},
-		orderHistory:     make([]bittrex.OrderResponse, 0),
+		orderHistory:     make([]exchange.Order, 0),
 		maxHistoryLength: 10000, // TODO replace with database or flat files? Do we even need to? https://github.com/mongodb/mongo-go-driver
-		currentOrder:     bittrex.OrderResponse{},
+		currentOrder:     exchange.Order{},
+		uncertainOrders:  make([]exchange.OrderRequest, 0),
 		currentTrail:     decimal.Zero,
+		buyHistory:       make([]exchange.Candle, 0),
+		sellHistory:      make([]exchange.Candle, 0),
+	}
+	for _, option := range options {
+		option(&babyBot)
+	}
+	if babyBot.Name == "" {
+		babyBot.Name = symbol
+	}
+	babyBot.log = logger.With("bot", babyBot.Name)
+	if _, ok := intervalToSleepSeconds[babyBot.Interval]; !ok {
+		return nil, fmt.Errorf("%w: the bot can't trade %q candles", exchange.ErrUnsupported, babyBot.Interval)
+	}
+	err := babyBot.periods.validate()
+	if err != nil {
+		return nil, err
+	}
+	err = babyBot.Setup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &babyBot, nil
+}
+
+// candleDir is where testing mode keeps historical candles
+func candleDir() string {
+	dir := os.Getenv("CANDLE_DIR")
+	if dir == "" {
+		return filepath.Join("data", "candles")
+	}
+	return dir
+}
+
+// startMockExchange replays the scenario in MOCK_SCENARIO, or the default week of the bot's symbol and interval.
+// Candles the local store is missing are downloaded first unless CANDLE_OFFLINE is set.
+func startMockExchange(ctx context.Context, symbol string, interval string) (*bittrex.MockExchange, error) {
+	scenario := bittrex.DefaultScenario()
+	scenario.Symbols = []string{symbol}
+	scenario.Intervals = []string{bittrex.CandleIntervals[interval]}
+	scenario.Data = candleDir()
+	if name := os.Getenv("MOCK_SCENARIO"); name != "" {
+		var err error
+		scenario, err = bittrex.LoadScenario(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if info, err := os.Stat(scenario.Data); err != nil || info.IsDir() {
+		candles, err := candlestore.Open(scenario.Data)
+		if err != nil {
+			return nil, err
+		}
+		var upstream candlestore.Source = bittrex.NewClient()
+		if os.Getenv("CANDLE_OFFLINE") != "" {
+			upstream = nil
+		}
+		downloader := candlestore.NewDownloader(candles, upstream)
+		for _, symbol := range scenario.Symbols {
+			for _, interval := range scenario.Intervals {
+				from := scenario.Start.Add(-bittrex.RecentCandlesWindow(interval))
+				_, err := downloader.Fill(ctx, symbol, interval, from, scenario.End)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	mock, err := bittrex.NewMockExchange(scenario)
+	if err != nil {
+		return nil, err
 	}
-	babyBot.Setup()
-	return &babyBot
+	return mock, mock.Start()
 }
 
-// Setup populates a new bot with data and starts the calculations rolling. Errors during this stage are fatal.
-func (bot *Bot) Setup() {
+// Setup populates a new bot with data and starts the calculations rolling. A bot that fails to set up can't run.
+func (bot *Bot) Setup(ctx context.Context) error {
 	// Point at historical data in testing mode
 	if bot.Mode == Modes["Testing"] {
-		logger.Info("Running in testing mode: starting fake server")
-		bittrex.SetBaseURL("http://localhost:8000")
-		go bittrex.StartMockServer()
+		bot.log.Info("Running in testing mode: starting fake server")
+		mock, err := startMockExchange(ctx, bot.Symbol, bot.Interval)
+		if err != nil {
+			return fmt.Errorf("starting the mock exchange: %w", err)
+		}
+		bot.mock = mock
+		// The mock server replays history as fast as it is asked, so there is nothing to rate limit
+		bot.client = bittrex.NewExchange(bittrex.NewClient(bittrex.WithBaseURL(mock.URL()), bittrex.WithSocketURL(mock.SocketURL()), bittrex.WithRateLimiter(nil)))
 	}
-	bot.SayHi()
-	logger.Info("Getting things ready...")
-	// Get starting data
-	recentCandles, err := bittrex.GetCandles(bot.Symbol, bot.Interval)
+	err := bot.setup(ctx)
 	if err != nil {
-		logger.Fatal(err)
+		bot.closeMock()
 	}
-	// Calculate the sma and first tema based on bot's period
-	bot.candleHistory = append(bot.candleHistory, recentCandles[:bot.Period]...)
-	sma, err := CandlesToSMA(recentCandles[:bot.Period])
+	return err
+}
+
+func (bot *Bot) setup(ctx context.Context) error {
+	err := bot.SayHi(ctx)
 	if err != nil {
-		logger.Fatal(err)
+		return err
+	}
+	bot.log.Info("Getting things ready...")
+	// Pick up the position from the last run. Backtests always start flat.
+	if bot.Mode != Modes["Testing"] {
+		err := bot.RestoreState(bot.statePath())
+		if err != nil {
+			return fmt.Errorf("restoring state: %w", err)
+		}
+		if bot.currentOrder.ID != "" {
+			bot.log.Infof("Picking up order %s with the trail at %s", bot.currentOrder.ID, bot.currentTrail)
+		}
 	}
-	firstTema, err := CandleToTEMA(recentCandles[bot.Period*2], sma, bot.smoothingModifier())
+	// Get starting data
+	recentCandles, err := bot.client.Candles(ctx, bot.Symbol, bot.Interval)
 	if err != nil {
-		logger.Fatal(err)
+		return wrapStage(ErrCandles, err)
 	}
+	period := bot.periods.TEMA
+	if len(recentCandles) < period*3+1 {
+		return wrapStage(ErrCandles, fmt.Errorf("only %d candles to start from", len(recentCandles)))
+	}
+	// Calculate the sma and first tema based on bot's period
+	bot.candleHistory = append(bot.candleHistory, recentCandles[:period]...)
+	sma := CandlesToSMA(recentCandles[:period])
+	firstTema := CandleToTEMA(recentCandles[period*2], sma, bot.smoothingModifier())
 	bot.temaHistory = append(bot.temaHistory, firstTema)
 	// Calculate the tema for the remaining candles
-	remainingCandles := recentCandles[bot.Period*3 : len(recentCandles)-1]
+	remainingCandles := recentCandles[period*3 : len(recentCandles)-1]
 	for i := 0; i < len(remainingCandles); i++ {
-		err = bot.processCandleUpdate(remainingCandles[i])
-		if err != nil {
-			logger.Fatal(err)
-		}
+		bot.processCandleUpdate(remainingCandles[i])
 	}
 	// Go back and populate macd and signal values
-	for i := 26; i < len(bot.candleHistory); i++ {
+	for i := bot.periods.MACDSlow; i < len(bot.candleHistory); i++ {
 		history := bot.candleHistory[:i]
-		val, err := decimal.NewFromString(history[len(history)-1].Close)
-		if err != nil {
-			logger.Fatal(err)
-		}
-		macd, err := CalculateMACD(val, history)
+		macd, err := CalculateMACDPeriods(history[len(history)-1].Close, history, bot.periods.MACDFast, bot.periods.MACDSlow)
 		if err == ErrCalcMACDNotEnoughInfo {
 			continue
 		}
 		bot.macdHistory = append(bot.macdHistory, macd)
 		err = bot.updateSignal()
 		if err != nil && err != ErrCalcSignalNotEnoughInfo {
-			logger.Fatal(err)
+			return err
 		}
 	}
+	if len(bot.macdHistory) == 0 {
+		return ErrCalcMACDNotEnoughInfo
+	}
 	// Log startup info
 	macd := bot.macdHistory[len(bot.macdHistory)-1]
 	signal := bot.signalHistory[len(bot.signalHistory)-1]
 	histogram := CalculateHistogram(macd, signal)
-	logger.Infof("Starting SMA value was %s", sma.StringFixed(2))
-	logger.Infof("Current MACD is: %s, MACD Signal is: %s, and MACD Histogram is: %s", macd.StringFixed(2), signal.StringFixed(2), histogram.StringFixed(2))
-	logger.Infof("Bot is ready to go with %d candles processed", len(bot.candleHistory))
-	logger.Infof("The last close was %s", bot.candleHistory[len(bot.candleHistory)-1].Close)
-	bot.sleep()
+	bot.log.Infof("Starting SMA value was %s", sma.StringFixed(2))
+	bot.log.Infof("Current MACD is: %s, MACD Signal is: %s, and MACD Histogram is: %s", macd.StringFixed(2), signal.StringFixed(2), histogram.StringFixed(2))
+	bot.log.Infof("Bot is ready to go with %d candles processed", len(bot.candleHistory))
+	bot.log.Infof("The last close was %s", bot.candleHistory[len(bot.candleHistory)-1].Close)
+	return nil
 }
 
-// Run runs the trading bot
-func (bot *Bot) Run() {
-	err := bot.SingleRotation(bot.Symbol)
-	if err != nil {
-		bot.checkErrorAndAct(err)
+// Run trades a rotation every interval until ctx is done, then saves the bot's state. Stopping only takes
+// effect between rotations, so a rotation that has started always finishes and never leaves an order half placed.
+// Run returns nil when it was stopped or a backtest ran out of history, and otherwise the error it couldn't
+// carry on from.
+func (bot *Bot) Run(ctx context.Context) error {
+	defer bot.closeMock()
+
+	// Testing mode replays history as fast as the mock exchange is asked, so there is nothing to wait for
+	var tick <-chan time.Time
+	if bot.Mode != Modes["Testing"] {
+		ticker := time.NewTicker(time.Duration(intervalToSleepSeconds[bot.Interval]) * time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var terminal error
+	for terminal == nil && bot.wait(ctx, tick) {
+		// Rotations are only cut off by their own timeout, not by stopping
+		err := bot.SingleRotation(context.Background(), bot.Symbol)
+		if err != nil {
+			terminal = bot.checkErrorAndAct(ctx, err)
+		}
+	}
+
+	if terminal != nil && !errors.Is(terminal, ErrBacktestFinished) {
+		bot.log.Error("Bot stopped: ", terminal)
 	} else {
-		bot.sleep()
+		bot.log.Info("Bot stopped")
+	}
+	if bot.Mode != Modes["Testing"] {
+		err := bot.SaveState(bot.statePath())
+		if err != nil {
+			bot.log.Error("Could not save the bot's state: ", err)
+		}
+	}
+	if errors.Is(terminal, ErrBacktestFinished) {
+		return nil
+	}
+	return terminal
+}
+
+func (bot *Bot) closeMock() {
+	if bot.mock != nil {
+		bot.mock.Close()
+		bot.mock = nil
+	}
+}
+
+// wait blocks until the next rotation is due, and says whether there should be one
+func (bot *Bot) wait(ctx context.Context, tick <-chan time.Time) bool {
+	if tick == nil {
+		bot.log.Debug("Starting next cycle")
+		return ctx.Err() == nil
+	}
+	bot.log.Info("Sleeping")
+	select {
+	case <-ctx.Done():
+		return false
+	case <-tick:
+		return true
 	}
 }
 
-// SingleRotation runs the bot trading logic once
-func (bot *Bot) SingleRotation(symbol string) error {
-	logger.Info("Starting rotation")
+// SingleRotation runs the bot trading logic once. Calls to the exchange are cut off if the rotation takes too long.
+func (bot *Bot) SingleRotation(ctx context.Context, symbol string) error {
+	bot.log.Info("Starting rotation")
+	ctx, cancel := context.WithTimeout(ctx, bot.rotationTimeout)
+	defer cancel()
 
 	// Check that api is alive
-	err := bittrex.PokeAPI()
+	err := bot.client.Ping(ctx)
 	if err != nil {
-		logger.Error(err)
-		return ErrPing
+		bot.log.Error(err)
+		return wrapStage(ErrPing, err)
 	}
 
 	// Get current tcandles of whatever symbol is being tracked
-	candles, err := bittrex.GetCandles(symbol, bot.Interval)
+	candles, err := bot.client.Candles(ctx, symbol, bot.Interval)
 	if err != nil {
-		return ErrCandles
+		return wrapStage(ErrCandles, err)
 	}
 
 	// Process that ticker and convert it into useful stats
-	err = bot.processCandlesUpdate(candles)
-	if err != nil {
-		return err // TODO gracefully handle this error
-	}
+	bot.processCandlesUpdate(candles)
 
 	// Calculate the macd on the current symbol
 	err = bot.updateMACD()
@@ -183,8 +368,18 @@ func (bot *Bot) SingleRotation(symbol string) error {
 		return err
 	}
 
-	// Decide what to do based on current data
-	err = bot.decideRoundAction()
+	// Work out what happened to any orders we lost track of before placing more
+	if len(bot.uncertainOrders) > 0 {
+		bot.reconcileOrders(ctx)
+	}
+
+	// Let the strategy decide what to do based on current data
+	snapshot := bot.snapshot(ctx)
+	intents, err := bot.strategy.Decide(snapshot)
+	if err != nil {
+		return fmt.Errorf("%s strategy: %w", bot.strategy.Name(), err)
+	}
+	err = bot.act(ctx, intents)
 	if err != nil {
 		return err
 	}
@@ -194,77 +389,75 @@ func (bot *Bot) SingleRotation(symbol string) error {
 	return nil
 }
 
-func (bot *Bot) checkErrorAndAct(err error) {
-	switch err {
-	case ErrPing:
-		logger.Error("API Ping failed.")
-		bot.sleep()
-	case ErrCandles:
-		logger.Error("Failed to get Candle information.")
+// checkErrorAndAct handles an error from a rotation. It returns the error back if the bot can't carry on.
+func (bot *Bot) checkErrorAndAct(ctx context.Context, err error) error {
+	var uncertain *exchange.UncertainOrderError
+	switch {
+	case errors.Is(err, exchange.ErrBadCredentials):
+		bot.log.Errorf("%s rejected our credentials: %s", bot.client.Name(), err)
+		return err
+	case errors.Is(err, exchange.ErrThrottled):
+		bot.log.Warnf("%s is throttling us, backing off.", bot.client.Name())
+		bot.backOff(ctx)
+	case errors.Is(err, exchange.ErrInsufficientFunds), errors.Is(err, exchange.ErrMinTradeRequirementNotMet):
+		bot.log.Errorf("%s turned down the order: %s", bot.client.Name(), err)
+		SendSlackLogging(err.Error())
+	case errors.Is(err, ErrInvalidOrder):
+		bot.log.Error("Order did not pass validation: ", err)
+	case errors.Is(err, ErrPing):
+		bot.log.Error("API Ping failed.")
+	case errors.Is(err, ErrCandles):
+		bot.log.Error("Failed to get Candle information.")
 		if bot.Mode == Modes["Testing"] {
-			logger.Info("Current Order:", bot.currentOrder)
-			logger.Info("Current Trail:", bot.currentTrail)
-			logger.Info("Order History:", len(bot.orderHistory))
-			printStats()
-			logger.Info(bot.orderHistory)
-			SelfDestruct <- true
+			bot.log.Info("Current Order:", bot.currentOrder)
+			bot.log.Info("Current Trail:", bot.currentTrail)
+			bot.log.Info("Order History:", len(bot.orderHistory))
+			bot.printStats()
+			bot.log.Info(bot.orderHistory)
+			return wrapStage(ErrBacktestFinished, err)
 		}
-		bot.sleep()
-	case ErrTicker:
-		logger.Error("Failed to get ticker information.")
-		bot.sleep()
-	case ErrCalcMACDNotEnoughInfo:
-		logger.Info("😴 Not enough info to calculate MACD.")
-		bot.sleep()
-	case ErrCalcSignalNotEnoughInfo:
-		logger.Info("😴 Not enough info to calculate Signal.")
-		bot.sleep()
+	case errors.Is(err, ErrTicker):
+		bot.log.Error("Failed to get ticker information.")
+	case errors.As(err, &uncertain):
+		bot.log.Warnf("Lost track of order %s, it has to be reconciled before trading again: %s", uncertain.Order.ClientOrderID, uncertain.Err)
+	case errors.Is(err, ErrCalcMACDNotEnoughInfo):
+		bot.log.Info("😴 Not enough info to calculate MACD.")
+	case errors.Is(err, ErrCalcSignalNotEnoughInfo):
+		bot.log.Info("😴 Not enough info to calculate Signal.")
+	case errors.Is(err, ErrCalcATRNotEnoughInfo):
+		bot.log.Info("😴 Not enough info to calculate ATR.")
 	default:
-		logger.Error(err)
-		SelfDestruct <- true
+		return err
 	}
+	return nil
 }
 
-func (bot *Bot) sleep() {
-	if bot.Mode == Modes["Testing"] {
-		logger.Debug("Starting next cycle")
-	} else {
-		logger.Info("Sleeping")
-		time.Sleep(time.Duration(intervalToSleepSeconds[bot.Interval]) * time.Second)
+func (bot *Bot) backOff(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(bot.throttleBackoff):
 	}
-	bot.Run()
 }
 
-func (bot *Bot) processCandleUpdate(candle bittrex.CandleResponse) error {
+func (bot *Bot) processCandleUpdate(candle exchange.Candle) {
 	bot.candleHistory = append(bot.candleHistory, candle)
-	tema, err := CandleToTEMA(candle, bot.temaHistory[len(bot.temaHistory)-1], bot.smoothingModifier())
-	if err != nil {
-		return err
-	}
+	tema := CandleToTEMA(candle, bot.temaHistory[len(bot.temaHistory)-1], bot.smoothingModifier())
 	bot.temaHistory = append(bot.temaHistory, tema)
-	return nil
 }
 
-func (bot *Bot) processCandlesUpdate(candles []bittrex.CandleResponse) error {
-	for i := 1; i < bot.Period+1; i++ {
-		err := bot.processCandleUpdate(candles[len(candles)-i])
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+// processCandlesUpdate takes in the latest candle. A rotation runs every interval, and the MACD and signal
+// move on one value a rotation, so one candle a rotation keeps them all in step.
+func (bot *Bot) processCandlesUpdate(candles []exchange.Candle) {
+	bot.processCandleUpdate(candles[len(candles)-1])
 }
 
 func (bot *Bot) smoothingModifier() decimal.Decimal {
-	return CalculateEMASmoothing(bot.Period)
+	return CalculateEMASmoothing(bot.periods.TEMA)
 }
 
 func (bot *Bot) updateMACD() error {
-	mostRecentValue, err := decimal.NewFromString(bot.candleHistory[len(bot.candleHistory)-1].Close)
-	if err != nil {
-		return err
-	}
-	macd, err := CalculateMACD(mostRecentValue, bot.candleHistory)
+	mostRecentValue := bot.candleHistory[len(bot.candleHistory)-1].Close
+	macd, err := CalculateMACDPeriods(mostRecentValue, bot.candleHistory, bot.periods.MACDFast, bot.periods.MACDSlow)
 	if err != nil {
 		return err
 	}
@@ -273,7 +466,7 @@ func (bot *Bot) updateMACD() error {
 }
 
 func (bot *Bot) updateSignal() error {
-	signal, err := CalculateSignalLine(bot.macdHistory, bot.signalHistory[len(bot.signalHistory)-1])
+	signal, err := CalculateSignalLinePeriod(bot.macdHistory, bot.signalHistory[len(bot.signalHistory)-1], bot.periods.Signal)
 	if err != nil {
 		return err
 	}
@@ -283,116 +476,227 @@ func (bot *Bot) updateSignal() error {
 
 func (bot *Bot) cleanHistory() {
 	if len(bot.candleHistory) > bot.maxHistoryLength {
-		logger.Debug("Cleaning oldest candle records")
+		bot.log.Debug("Cleaning oldest candle records")
 		bot.candleHistory = bot.candleHistory[len(bot.candleHistory)-bot.maxHistoryLength:]
 	}
 	if len(bot.temaHistory) > bot.maxHistoryLength {
-		logger.Debug("Cleaning oldest tema records")
+		bot.log.Debug("Cleaning oldest tema records")
 		bot.temaHistory = bot.temaHistory[len(bot.temaHistory)-bot.maxHistoryLength:]
 	}
 	if len(bot.macdHistory) > bot.maxHistoryLength {
-		logger.Debug("Cleaning oldest macd records")
+		bot.log.Debug("Cleaning oldest macd records")
 		bot.macdHistory = bot.macdHistory[len(bot.macdHistory)-bot.maxHistoryLength:]
 	}
 	if len(bot.signalHistory) > bot.maxHistoryLength {
-		logger.Debug("Cleaning oldest signal records")
+		bot.log.Debug("Cleaning oldest signal records")
 		bot.signalHistory = bot.signalHistory[len(bot.signalHistory)-bot.maxHistoryLength:]
 	}
 }
 
-func (bot *Bot) decideRoundAction() error {
-	var (
-		tema           = bot.temaHistory[len(bot.temaHistory)-1]
-		macd           = bot.macdHistory[len(bot.macdHistory)-1]
-		signal         = bot.signalHistory[len(bot.signalHistory)-1]
-		histogram      = CalculateHistogram(macd, signal)
-		currentOrderID = bot.currentOrder.ID
-	)
-
-	// Update the trail
-	if currentOrderID != "" {
-		// Update the trail if price has gone up
-		if tema.GreaterThan(bot.currentTrail) {
-			bot.currentTrail = tema.Sub(bot.trailLag)
-			logger.Infof("New trail is at %s", bot.currentTrail.StringFixed(2))
-		}
-
-		err := bot.decideShouldSell(tema, histogram, currentOrderID)
-		if err != nil {
-			return err
-		}
+// snapshot is what the strategy gets to decide on this rotation
+func (bot *Bot) snapshot(ctx context.Context) Snapshot {
+	macd := bot.macdHistory[len(bot.macdHistory)-1]
+	signal := bot.signalHistory[len(bot.signalHistory)-1]
+	snapshot := Snapshot{
+		Symbol:  bot.Symbol,
+		Candles: bot.candleHistory,
+		Indicators: Indicators{
+			TEMA:          bot.temaHistory[len(bot.temaHistory)-1],
+			MACD:          macd,
+			Signal:        signal,
+			Histogram:     CalculateHistogram(macd, signal),
+			TEMAHistory:   bot.temaHistory,
+			MACDHistory:   bot.macdHistory,
+			SignalHistory: bot.signalHistory,
+		},
+		Position: Position{Order: bot.currentOrder, Trail: bot.currentTrail},
+	}
+	// Without enough candles the ATR stays zero, and strategies with ATR thresholds hold until there is one
+	atr, err := CandlesToATR(bot.candleHistory, bot.periods.ATR)
+	if err == nil {
+		snapshot.Indicators.ATR = atr
+	}
+	balances, err := bot.client.Balances(ctx)
+	if err != nil {
+		bot.log.Warn("Could not get balances for the strategy: ", err)
 	} else {
-		err := bot.decideShouldBuy(tema, histogram)
-		if err != nil {
-			return err
-		}
+		snapshot.Balances = balances
 	}
-
-	return nil
+	return snapshot
 }
 
-func (bot *Bot) decideShouldSell(tema decimal.Decimal, histogram decimal.Decimal, currentOrderID string) error {
-	// close, _ := decimal.NewFromString(bot.candleHistory[len(bot.candleHistory)-1].Close)
-	if tema.LessThan(bot.currentTrail) { // This is the issue - need to fail faster!!!! But taper this control with the histogram so that it does not fail too fast //  && histogram.LessThan(decimal.NewFromInt(2))
-		start, _ := decimal.NewFromString(bot.currentOrder.ID)
-		goalGain := start.Add(decimal.NewFromInt(10))
-		if tema.GreaterThan(goalGain) {
-			logger.Info("Making a sell")
-
-			copy := bot.currentOrder
-			// copy.Direction = "sell"
-			copy.ID = bot.candleHistory[len(bot.candleHistory)-1].Close + "candle"
-			copy.MarketSymbol = tema.StringFixed(2) + "tema"
-			copy.Direction = bot.currentTrail.StringFixed(2) + "trail"
-			copy.CreatedAt = bot.candleHistory[len(bot.candleHistory)-1].StartsAt
-			copy.OrderToCancel.ID = currentOrderID
-			copy.Status = histogram.StringFixed(2) + "histogram"
-			saveSell(bot.candleHistory[len(bot.candleHistory)-1])
-
-			bot.orderHistory = append(bot.orderHistory, copy)
-			bot.currentTrail = decimal.Zero
-			bot.currentOrder = bittrex.OrderResponse{}
+// act carries out what the strategy decided
+func (bot *Bot) act(ctx context.Context, intents []Intent) error {
+	for _, intent := range intents {
+		switch intent.Action {
+		case Hold:
+		case AdjustStop:
+			bot.currentTrail = intent.Stop
+			bot.log.Infof("New trail is at %s", bot.currentTrail.StringFixed(2))
+		case Buy:
+			if bot.currentOrder.ID != "" {
+				bot.log.Warnf("%s wants to buy, but the bot is already holding order %s", bot.strategy.Name(), bot.currentOrder.ID)
+				continue
+			}
+			if len(bot.uncertainOrders) > 0 {
+				bot.log.Warnf("Skipping purchase until %d uncertain orders are reconciled", len(bot.uncertainOrders))
+				continue
+			}
+			bot.log.Info("Attempting to make a purchase: ", intent.Reason)
+			if err := bot.buy(ctx, intent.Limit); err != nil {
+				return err
+			}
+			// Orders that closed without filling leave the bot flat and aren't trades
+			if bot.Mode == Modes["Testing"] && bot.currentOrder.ID != "" {
+				bot.orderHistory = append(bot.orderHistory, bot.currentOrder)
+				bot.saveBuy(bot.candleHistory[len(bot.candleHistory)-1])
+			}
+		case Sell:
+			if bot.currentOrder.ID == "" {
+				bot.log.Warnf("%s wants to sell, but the bot isn't holding anything", bot.strategy.Name())
+				continue
+			}
+			if len(bot.uncertainOrders) > 0 {
+				bot.log.Warnf("Skipping sale until %d uncertain orders are reconciled", len(bot.uncertainOrders))
+				continue
+			}
+			bot.log.Info("Making a sell: ", intent.Reason)
+			sale, err := bot.sell(ctx, intent.Limit)
+			if err != nil {
+				return err
+			}
+			// Orders that closed without filling leave the bot holding and aren't trades
+			if bot.Mode == Modes["Testing"] && sale.ID != "" {
+				bot.orderHistory = append(bot.orderHistory, sale)
+				bot.saveSell(bot.candleHistory[len(bot.candleHistory)-1])
+			}
+		default:
+			return fmt.Errorf("%s wants to %q, which the bot doesn't know how to do", bot.strategy.Name(), intent.Action)
 		}
 	}
 	return nil
 }
 
-func (bot *Bot) decideShouldBuy(tema decimal.Decimal, histogram decimal.Decimal) error {
-	if histogram.GreaterThan(decimal.NewFromInt(6)) {
-		logger.Info("Attempting to make a purchase")
-		if limit, err := decimal.NewFromString(bot.candleHistory[len(bot.candleHistory)-1].Close); err == nil {
-			if err := bot.buy(limit); err != nil {
-				return err
-			}
-		}
-		if bot.Mode == Modes["Testing"] {
-			bot.orderHistory = append(bot.orderHistory, bot.currentOrder)
-			saveBuy(bot.candleHistory[len(bot.candleHistory)-1])
-		}
+// buy spends the least the market allows on an immediate or cancel order at limit, and holds on to it if any of
+// it filled
+func (bot *Bot) buy(ctx context.Context, limit decimal.Decimal) error {
+	if bot.Mode == Modes["Paper"] {
+		return nil
+	}
+	market, err := bot.client.Market(ctx, bot.Symbol)
+	if err != nil {
+		return wrapStage(ErrNetNewOrder, err)
+	}
+	order, err := bot.placeOrder(ctx, market, exchange.Buy, market.MinQuantity, limit)
+	if err != nil {
+		return err
 	}
+	bot.trackPurchase(ctx, order)
 	return nil
 }
 
-func (bot *Bot) buy(limit decimal.Decimal) error {
-	if bot.Mode != Modes["Paper"] {
-		limit64, _ := limit.Float64()
-		newOrder := bittrex.NewOrder{
-			MarketSymbol: bot.Symbol,
-			Direction:    "BUY",
-			Type:         "LIMIT",
-			Quantity:     0.000000000000001, //TODO!
-			Limit:        limit64,
-			TimeInForce:  "IMMEDIATE_OR_CANCEL",
-		}
-		orderResponse, err := bittrex.Order(newOrder)
-		if err != nil {
-			return ErrNetNewOrder
+// sell offers everything the bot is holding on an immediate or cancel order at limit. The position is closed
+// if any of it sold, and the sale is returned; it's empty when nothing sold.
+func (bot *Bot) sell(ctx context.Context, limit decimal.Decimal) (exchange.Order, error) {
+	market, err := bot.client.Market(ctx, bot.Symbol)
+	if err != nil {
+		return exchange.Order{}, wrapStage(ErrNetNewOrder, err)
+	}
+	order, err := bot.placeOrder(ctx, market, exchange.Sell, bot.currentOrder.FilledQuantity, limit)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return bot.trackSale(ctx, order), nil
+}
+
+// placeOrder places an immediate or cancel limit order. Requests that were cut off are kept to be reconciled.
+func (bot *Bot) placeOrder(ctx context.Context, market exchange.MarketInfo, side string, quantity decimal.Decimal, limit decimal.Decimal) (exchange.Order, error) {
+	request, err := exchange.ValidateOrder(exchange.OrderRequest{
+		Symbol:      bot.Symbol,
+		Side:        side,
+		Type:        exchange.Limit,
+		Quantity:    quantity,
+		Limit:       limit,
+		TimeInForce: exchange.ImmediateOrCancel,
+	}, market)
+	if err != nil {
+		return exchange.Order{}, wrapStage(ErrInvalidOrder, err)
+	}
+	order, err := bot.client.PlaceOrder(ctx, request)
+	var uncertain *exchange.UncertainOrderError
+	if errors.As(err, &uncertain) {
+		bot.uncertainOrders = append(bot.uncertainOrders, uncertain.Order)
+		return exchange.Order{}, err
+	}
+	if err != nil {
+		return exchange.Order{}, wrapStage(ErrNetNewOrder, err)
+	}
+	return order, nil
+}
+
+// settle waits out an immediate or cancel order the exchange answered for before it closed
+func (bot *Bot) settle(ctx context.Context, order exchange.Order) exchange.Order {
+	if order.Status != exchange.Open {
+		return order
+	}
+	latest, err := bot.client.GetOrder(ctx, order.Symbol, order.ID)
+	if err != nil {
+		bot.log.Warn("Could not check on order: ", err)
+		return order
+	}
+	return latest
+}
+
+// trackPurchase holds on to a buy order if any of it filled
+func (bot *Bot) trackPurchase(ctx context.Context, order exchange.Order) {
+	order = bot.settle(ctx, order)
+	if order.FilledQuantity.IsZero() {
+		bot.log.Infof("Order %s closed without filling", order.ID)
+		return
+	}
+	SendSlackFinancials(order)
+	bot.log.Warn("Made a purchase", order)
+	bot.currentOrder = order
+}
+
+// trackSale closes the position if any of a sell order filled, and returns the sale
+func (bot *Bot) trackSale(ctx context.Context, order exchange.Order) exchange.Order {
+	order = bot.settle(ctx, order)
+	if order.FilledQuantity.IsZero() {
+		bot.log.Infof("Order %s closed without filling, still holding %s", order.ID, bot.currentOrder.ID)
+		return exchange.Order{}
+	}
+	if order.FilledQuantity.LessThan(order.Quantity) {
+		bot.log.Warnf("Order %s only sold %s of %s, the rest is left on the account", order.ID, order.FilledQuantity, order.Quantity)
+	}
+	SendSlackFinancials(order)
+	bot.log.Warn("Made a sale", order)
+	bot.currentOrder = exchange.Order{}
+	bot.currentTrail = decimal.Zero
+	return order
+}
+
+// reconcileOrders looks up orders that were cut off, and drops the ones the exchange never saw
+func (bot *Bot) reconcileOrders(ctx context.Context) {
+	remaining := make([]exchange.OrderRequest, 0)
+	for _, order := range bot.uncertainOrders {
+		found, err := bot.client.FindOrder(ctx, order.Symbol, order.ClientOrderID)
+		switch {
+		case errors.Is(err, exchange.ErrNotFound):
+			bot.log.Infof("Order %s never made it to %s", order.ClientOrderID, bot.client.Name())
+		case err != nil:
+			bot.log.Warn("Could not reconcile order yet: ", err)
+			remaining = append(remaining, order)
+		default:
+			bot.log.Infof("Order %s made it to %s as %s", order.ClientOrderID, bot.client.Name(), found.ID)
+			if found.Side == exchange.Buy {
+				bot.trackPurchase(ctx, found)
+			} else {
+				bot.trackSale(ctx, found)
+			}
 		}
-		SendSlackFinancials(orderResponse)
-		logger.Warn("Made a purchase", orderResponse)
-		bot.currentOrder = orderResponse
 	}
-	return nil
+	bot.uncertainOrders = remaining
 }
 
 func (bot *Bot) logRoundStats() {
@@ -401,21 +705,21 @@ func (bot *Bot) logRoundStats() {
 	macd := bot.macdHistory[len(bot.macdHistory)-1]
 	signal := bot.signalHistory[len(bot.signalHistory)-1]
 	histogram := CalculateHistogram(macd, signal)
-	logger.Infof("The latest candle is from %s and closed at %s", candle.StartsAt, candle.Close)
-	logger.Infof("The TEMA came out to %s", tema.StringFixed(3))
-	logger.Infof("The MACD is %s, with a signal of %s", macd.StringFixed(2), signal.StringFixed(2))
-	logger.Infof("The histogram value is %s", histogram.StringFixed(2))
+	bot.log.Infof("The latest candle is from %s and closed at %s", candle.StartsAt, candle.Close)
+	bot.log.Infof("The TEMA came out to %s", tema.StringFixed(3))
+	bot.log.Infof("The MACD is %s, with a signal of %s", macd.StringFixed(2), signal.StringFixed(2))
+	bot.log.Infof("The histogram value is %s", histogram.StringFixed(2))
 }
 
 // SayHi is a smoke test
-func (bot *Bot) SayHi() {
-	err := bittrex.PokeAPI()
+func (bot *Bot) SayHi(ctx context.Context) error {
+	err := bot.client.Ping(ctx)
 	if err != nil {
-		logger.Fatal("💩", err)
+		return wrapStage(ErrPing, err)
 	}
-	account, err := bittrex.GetAccount()
+	account, err := bot.client.Account(ctx)
 	if err != nil {
-		logger.Fatal(err)
+		return err
 	}
 	message := `
    _____                  _         __       
@@ -428,6 +732,8 @@ func (bot *Bot) SayHi() {
               |___/|_|                  
 	`
 	fmt.Println(message)
-	logger.Infof("👋 Hello account %s!", account.AccountID)
-	logger.Infof("🚨 This bot instance is running in %s mode.", bot.Mode)
+	bot.log.Infof("👋 Hello %s account %s!", bot.client.Name(), account.ID)
+	bot.log.Infof("🚨 This bot instance is running in %s mode with the %s strategy.", bot.Mode, bot.strategy.Name())
+	bot.log.Infof("Indicator periods are TEMA %d, MACD %d/%d, signal %d and ATR %d", bot.periods.TEMA, bot.periods.MACDFast, bot.periods.MACDSlow, bot.periods.Signal, bot.periods.ATR)
+	return nil
 }
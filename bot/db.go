@@ -9,20 +9,19 @@ import (
 )
 
 // DBConnect connects to the db
-func DBConnect() {
+func DBConnect() error {
 	dbpool, err := pgxpool.Connect(context.Background(), os.Getenv("DATABASE_URL"))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("Unable to connect to database: %w", err)
 	}
 	defer dbpool.Close()
 
 	var greeting string
 	err = dbpool.QueryRow(context.Background(), "select 'Hello, world!'").Scan(&greeting)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "QueryRow failed: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("QueryRow failed: %w", err)
 	}
 
 	fmt.Println(greeting)
+	return nil
 }
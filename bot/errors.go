@@ -1,6 +1,9 @@
 package bot
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrPing means the api poke failed
@@ -13,6 +16,38 @@ var (
 	ErrCalcMACDNotEnoughInfo = errors.New("Not enough info to calculate a MACD value")
 	// ErrCalcSignalNotEnoughInfo means that you tried to calculate a signal without enough information
 	ErrCalcSignalNotEnoughInfo = errors.New("Not enough info to calculate a signal line value")
+	// ErrCalcATRNotEnoughInfo means that you tried to calculate an average true range without enough candles
+	ErrCalcATRNotEnoughInfo = errors.New("Not enough info to calculate an ATR value")
+	// ErrInvalidPeriods means a bot was configured with indicator periods that can't be calculated
+	ErrInvalidPeriods = errors.New("Invalid indicator periods")
+	// ErrInvalidThreshold means a strategy threshold couldn't be parsed
+	ErrInvalidThreshold = errors.New("Invalid threshold")
 	// ErrNetNewOrder means there was a network error while creating a new order
 	ErrNetNewOrder = errors.New("Network error while creating a new order")
+	// ErrInvalidOrder means an order was rounded to nothing or broke the market's rules, so it was never sent
+	ErrInvalidOrder = errors.New("Order did not pass validation")
+	// ErrBacktestFinished means testing mode replayed all the history it had
+	ErrBacktestFinished = errors.New("Backtest ran out of history")
 )
+
+// stageError keeps the reason a stage of the bot failed, so errors.Is matches both the stage and the cause
+type stageError struct {
+	stage error
+	err   error
+}
+
+func wrapStage(stage error, err error) error {
+	return &stageError{stage: stage, err: err}
+}
+
+func (e *stageError) Error() string {
+	return fmt.Sprintf("%s: %s", e.stage, e.err)
+}
+
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+func (e *stageError) Is(target error) bool {
+	return target == e.stage
+}
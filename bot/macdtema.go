@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// MACDTEMA buys when the MACD histogram runs well above its signal, then trails a stop under the TEMA and sells
+// once the TEMA drops through it with a good enough gain. Thresholds are worked out against the latest close, so
+// relative ones follow the price.
+type MACDTEMA struct {
+	// BuyHistogram is how far the histogram has to be above zero to buy
+	BuyHistogram Threshold `json:"buyHistogram"`
+	// SellGain is how far above the entry price the TEMA has to be to sell
+	SellGain Threshold `json:"sellGain"`
+	// TrailLag is how far under the TEMA the stop trails
+	TrailLag Threshold `json:"trailLag"`
+}
+
+// NewMACDTEMA is the strategy the bot has always traded with. Its absolute thresholds were tuned for BTC-USD,
+// so give other symbols relative ones.
+func NewMACDTEMA() *MACDTEMA {
+	return &MACDTEMA{
+		BuyHistogram: AbsoluteThreshold(decimal.NewFromInt(6)),
+		SellGain:     AbsoluteThreshold(decimal.NewFromInt(10)),
+		TrailLag:     AbsoluteThreshold(decimal.NewFromInt(5)),
+	}
+}
+
+// Name is macd-tema
+func (s *MACDTEMA) Name() string {
+	return "macd-tema"
+}
+
+// Decide buys on a strong histogram, and once holding raises the trail with the TEMA and sells when the TEMA
+// falls under it
+func (s *MACDTEMA) Decide(snapshot Snapshot) ([]Intent, error) {
+	tema := snapshot.Indicators.TEMA
+	histogram := snapshot.Indicators.Histogram
+	price := snapshot.Close()
+	atr := snapshot.Indicators.ATR
+
+	if !atr.IsPositive() && s.needsATR(snapshot.Position.Open()) {
+		return []Intent{{Action: Hold, Reason: "waiting on enough candles for an average true range"}}, nil
+	}
+
+	if !snapshot.Position.Open() {
+		buyHistogram, err := s.BuyHistogram.Resolve(price, atr)
+		if err != nil {
+			return nil, fmt.Errorf("buy histogram: %w", err)
+		}
+		if histogram.GreaterThan(buyHistogram) {
+			return []Intent{{Action: Buy, Limit: price, Reason: fmt.Sprintf("histogram is %s", histogram.StringFixed(2))}}, nil
+		}
+		return []Intent{{Action: Hold}}, nil
+	}
+
+	trailLag, err := s.TrailLag.Resolve(price, atr)
+	if err != nil {
+		return nil, fmt.Errorf("trail lag: %w", err)
+	}
+	sellGain, err := s.SellGain.Resolve(price, atr)
+	if err != nil {
+		return nil, fmt.Errorf("sell gain: %w", err)
+	}
+	intents := make([]Intent, 0)
+	trail := snapshot.Position.Trail
+	// Update the trail if price has gone up
+	if tema.GreaterThan(trail) {
+		trail = tema.Sub(trailLag)
+		intents = append(intents, Intent{Action: AdjustStop, Stop: trail, Reason: fmt.Sprintf("TEMA rose to %s", tema.StringFixed(2))})
+	}
+	// This is the issue - need to fail faster!!!! But taper this control with the histogram so that it does not fail too fast //  && histogram.LessThan(decimal.NewFromInt(2))
+	entry, ok := snapshot.Position.EntryPrice()
+	if !ok {
+		return nil, fmt.Errorf("order %s has no entry price to measure the gain from", snapshot.Position.Order.ID)
+	}
+	goalGain := entry.Add(sellGain)
+	if tema.LessThan(trail) && tema.GreaterThan(goalGain) {
+		intents = append(intents, Intent{Action: Sell, Limit: price, Reason: fmt.Sprintf("TEMA fell through the trail at %s", trail.StringFixed(2))})
+	}
+	if len(intents) == 0 {
+		intents = append(intents, Intent{Action: Hold})
+	}
+	return intents, nil
+}
+
+// needsATR says whether the thresholds used with or without a position are worked out from the ATR
+func (s *MACDTEMA) needsATR(open bool) bool {
+	if open {
+		return s.TrailLag.Unit == ATR || s.SellGain.Unit == ATR
+	}
+	return s.BuyHistogram.Unit == ATR
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+// State is what a bot needs to pick up where it left off: the position it holds and the orders it lost track of
+type State struct {
+	Symbol          string
+	Interval        string
+	SavedAt         time.Time
+	CurrentOrder    exchange.Order
+	CurrentTrail    decimal.Decimal
+	UncertainOrders []exchange.OrderRequest
+	OrderHistory    []exchange.Order
+}
+
+// stateDir is where bots keep their state between runs
+func stateDir() string {
+	dir := os.Getenv("STATE_DIR")
+	if dir == "" {
+		return filepath.Join("data", "state")
+	}
+	return dir
+}
+
+// statePath is the bot's state file, one per venue and bot name
+func (bot *Bot) statePath() string {
+	return filepath.Join(stateDir(), strings.ToLower(bot.client.Name()+"-"+bot.Name)+".json")
+}
+
+// State is a snapshot of the bot's position
+func (bot *Bot) State() State {
+	return State{
+		Symbol:          bot.Symbol,
+		Interval:        bot.Interval,
+		SavedAt:         time.Now().UTC(),
+		CurrentOrder:    bot.currentOrder,
+		CurrentTrail:    bot.currentTrail,
+		UncertainOrders: bot.uncertainOrders,
+		OrderHistory:    bot.orderHistory,
+	}
+}
+
+// SaveState writes the bot's state to path. It writes a temporary file and renames it, so a crash never
+// leaves half a state file.
+func (bot *Bot) SaveState(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(bot.State(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, content, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RestoreState picks up the position saved at path. A missing file means there is nothing to pick up.
+func (bot *Bot) RestoreState(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state State
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		return err
+	}
+	bot.currentOrder = state.CurrentOrder
+	bot.currentTrail = state.CurrentTrail
+	if state.UncertainOrders != nil {
+		bot.uncertainOrders = state.UncertainOrders
+	}
+	if state.OrderHistory != nil {
+		bot.orderHistory = state.OrderHistory
+	}
+	return nil
+}
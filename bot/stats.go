@@ -1,52 +1,35 @@
 package bot
 
 import (
-	"cryptofu/bittrex"
+	"cryptofu/exchange"
 	"fmt"
 
 	"github.com/shopspring/decimal"
 )
 
-var (
-	buyHistory  = make([]bittrex.CandleResponse, 0)
-	sellHistory = make([]bittrex.CandleResponse, 0)
-)
-
-func printStats() {
-	for i := 0; i < len(sellHistory); i++ {
-		buy, err := decimal.NewFromString(buyHistory[i].Close)
-		if err != nil {
-			continue
-		}
-		sell, err := decimal.NewFromString(sellHistory[i].Close)
-		if err != nil {
-			continue
-		}
-		diff := sell.Sub(buy)
-		fmt.Println("💰", diff.StringFixed(2))
+func (bot *Bot) printStats() {
+	for i := 0; i < len(bot.sellHistory); i++ {
+		diff := bot.sellHistory[i].Close.Sub(bot.buyHistory[i].Close)
+		fmt.Println(bot.Name, "💰", diff.StringFixed(2))
 	}
-	buys := sum(buyHistory)
-	sells := sum(sellHistory)
+	buys := sum(bot.buyHistory)
+	sells := sum(bot.sellHistory)
 	diff := sells.Sub(buys)
-	fmt.Println("Net 💰:", diff.StringFixed(2))
+	fmt.Println(bot.Name, "Net 💰:", diff.StringFixed(2))
 }
 
-func saveBuy(buy bittrex.CandleResponse) {
-	buyHistory = append(buyHistory, buy)
+func (bot *Bot) saveBuy(buy exchange.Candle) {
+	bot.buyHistory = append(bot.buyHistory, buy)
 }
 
-func saveSell(sell bittrex.CandleResponse) {
-	sellHistory = append(sellHistory, sell)
+func (bot *Bot) saveSell(sell exchange.Candle) {
+	bot.sellHistory = append(bot.sellHistory, sell)
 }
 
-func sum(array []bittrex.CandleResponse) decimal.Decimal {
+func sum(array []exchange.Candle) decimal.Decimal {
 	result := decimal.Zero
 	for _, v := range array {
-		num, err := decimal.NewFromString(v.Close)
-		if err != nil {
-			continue
-		}
-		result = result.Add(num)
+		result = result.Add(v.Close)
 	}
 	return result
 }
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Hold leaves things as they are
+	Hold = "HOLD"
+	// Buy opens a position
+	Buy = "BUY"
+	// Sell closes the open position
+	Sell = "SELL"
+	// AdjustStop moves the open position's trailing stop to Intent.Stop
+	AdjustStop = "ADJUST_STOP"
+)
+
+// Strategy decides what a bot should do. The bot handles everything else: fetching candles, working out
+// indicators, placing orders and keeping track of the position.
+type Strategy interface {
+	// Name says which strategy this is, for logs
+	Name() string
+	// Decide looks at the market and says what to do about it. Intents are carried out in order.
+	Decide(snapshot Snapshot) ([]Intent, error)
+}
+
+// Snapshot is everything a strategy gets to decide on, as of the latest candle
+type Snapshot struct {
+	Symbol  string
+	Candles []exchange.Candle
+	// Indicators are the latest values, the histories are oldest first
+	Indicators Indicators
+	Position   Position
+	// Balances are nil when they couldn't be fetched
+	Balances []exchange.Balance
+}
+
+// Indicators are what the bot works out from the candles every rotation
+type Indicators struct {
+	TEMA      decimal.Decimal
+	MACD      decimal.Decimal
+	Signal    decimal.Decimal
+	Histogram decimal.Decimal
+	// ATR is zero until there are enough candles for it
+	ATR           decimal.Decimal
+	TEMAHistory   []decimal.Decimal
+	MACDHistory   []decimal.Decimal
+	SignalHistory []decimal.Decimal
+}
+
+// Position is what the bot is holding. Order is empty when it holds nothing.
+type Position struct {
+	Order exchange.Order
+	Trail decimal.Decimal
+}
+
+// Open says whether the bot is holding anything
+func (p Position) Open() bool {
+	return p.Order.ID != ""
+}
+
+// EntryPrice is what the position was bought at: the average fill price when the venue said what the order
+// filled for, otherwise its limit. It's false when the order says neither.
+func (p Position) EntryPrice() (decimal.Decimal, bool) {
+	if p.Order.FilledQuantity.IsPositive() && p.Order.Proceeds.IsPositive() {
+		return p.Order.Proceeds.Div(p.Order.FilledQuantity), true
+	}
+	if p.Order.Limit.IsPositive() {
+		return p.Order.Limit, true
+	}
+	return decimal.Zero, false
+}
+
+// Intent is something a strategy wants done. Limit is the price to trade at for buys and sells, and Stop is
+// where to move the trailing stop for AdjustStop.
+type Intent struct {
+	Action string
+	Limit  decimal.Decimal
+	Stop   decimal.Decimal
+	Reason string
+}
+
+// Close is the latest candle's close, or zero without candles
+func (s Snapshot) Close() decimal.Decimal {
+	if len(s.Candles) == 0 {
+		return decimal.Zero
+	}
+	return s.Candles[len(s.Candles)-1].Close
+}
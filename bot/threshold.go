@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Absolute thresholds are in the quote currency, like the bot's original 6, 10 and 5
+	Absolute = ""
+	// Percent thresholds are a percentage of the latest close
+	Percent = "%"
+	// ATR thresholds are multiples of the average true range
+	ATR = "atr"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Threshold is a distance in price. Relative thresholds let one strategy config trade symbols worth fractions
+// of a cent and symbols worth thousands alike. In config they are written as "6", "0.5%" or "1.5atr".
+type Threshold struct {
+	Amount decimal.Decimal
+	Unit   string
+}
+
+// AbsoluteThreshold is amount in the quote currency
+func AbsoluteThreshold(amount decimal.Decimal) Threshold {
+	return Threshold{Amount: amount, Unit: Absolute}
+}
+
+// PercentThreshold is percent of the latest close
+func PercentThreshold(percent decimal.Decimal) Threshold {
+	return Threshold{Amount: percent, Unit: Percent}
+}
+
+// ATRThreshold is multiple times the average true range
+func ATRThreshold(multiple decimal.Decimal) Threshold {
+	return Threshold{Amount: multiple, Unit: ATR}
+}
+
+// ParseThreshold reads a threshold written as "6", "0.5%" or "1.5atr"
+func ParseThreshold(s string) (Threshold, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	unit := Absolute
+	if strings.HasSuffix(s, Percent) {
+		unit = Percent
+	} else if strings.HasSuffix(s, ATR) {
+		unit = ATR
+	}
+	amount, err := decimal.NewFromString(strings.TrimSpace(strings.TrimSuffix(s, unit)))
+	if err != nil {
+		return Threshold{}, fmt.Errorf("%w: %q", ErrInvalidThreshold, s)
+	}
+	return Threshold{Amount: amount, Unit: unit}, nil
+}
+
+// Resolve works out the threshold in the quote currency at price, given the latest average true range. ATR
+// thresholds can't be worked out until there are enough candles for one, which is ErrCalcATRNotEnoughInfo.
+func (t Threshold) Resolve(price decimal.Decimal, atr decimal.Decimal) (decimal.Decimal, error) {
+	switch t.Unit {
+	case Absolute:
+		return t.Amount, nil
+	case Percent:
+		return price.Mul(t.Amount).Div(hundred), nil
+	case ATR:
+		if !atr.IsPositive() {
+			return decimal.Zero, fmt.Errorf("%w: %s needs one", ErrCalcATRNotEnoughInfo, t)
+		}
+		return atr.Mul(t.Amount), nil
+	default:
+		return decimal.Zero, fmt.Errorf("%w: unknown unit %q", ErrInvalidThreshold, t.Unit)
+	}
+}
+
+func (t Threshold) String() string {
+	return t.Amount.String() + t.Unit
+}
+
+// MarshalJSON writes the threshold the way ParseThreshold reads it
+func (t Threshold) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON takes a plain number as an absolute threshold, or a string ParseThreshold understands
+func (t *Threshold) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var amount decimal.Decimal
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidThreshold, data)
+		}
+		*t = AbsoluteThreshold(amount)
+		return nil
+	}
+	parsed, err := ParseThreshold(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"cryptofu/bittrex"
 	"cryptofu/bot"
+	"cryptofu/candlestore"
+	"cryptofu/exchange"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
 var (
-	exampleCandles = func() []bittrex.CandleResponse {
-		data := make([]bittrex.CandleResponse, 0)
+	exampleCandles = func() []exchange.Candle {
+		data := make([]exchange.Candle, 0)
 		for i := 1; i < 31; i++ {
 			data = append(data, createDemoCandleResponse(i))
 		}
@@ -19,9 +27,9 @@ var (
 	}()
 )
 
-func createDemoCandleResponse(i int) bittrex.CandleResponse {
-	return bittrex.CandleResponse{
-		Close: fmt.Sprintf("%d", 20000+i),
+func createDemoCandleResponse(i int) exchange.Candle {
+	return exchange.Candle{
+		Close: decimal.NewFromInt(int64(20000 + i)),
 	}
 }
 
@@ -35,15 +43,25 @@ func td(num int64) decimal.Decimal {
 	return decimal.NewFromInt(num)
 }
 
+// setenv sets an environment variable for the rest of the test
+func setenv(t *testing.T, key string, value string) {
+	old, had := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
 /*
 	analysis.go
 */
 
 func TestCandlesToSMA(t *testing.T) {
-	got, err := bot.CandlesToSMA(exampleCandles)
-	if err != nil {
-		t.Error(err)
-	}
+	got := bot.CandlesToSMA(exampleCandles)
 	checkStringFixed(got, 2, "20015.50", t)
 }
 
@@ -116,7 +134,273 @@ func TestCalculateSignalLine(t *testing.T) {
 	checkStringFixed(got, 2, "9.17", t)
 }
 
+func TestCalculateMACDPeriods(t *testing.T) {
+	number := decimal.NewFromInt(20049)
+	// The textbook periods match CalculateMACD
+	got, err := bot.CalculateMACDPeriods(number, exampleCandles, 12, 26)
+	if err != nil {
+		t.Error(err)
+	}
+	checkStringFixed(got, 2, "8.44", t)
+	// Shorter periods need fewer candles
+	got, err = bot.CalculateMACDPeriods(number, exampleCandles[:10], 3, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	checkStringFixed(got, 2, "15.59", t)
+	_, err = bot.CalculateMACDPeriods(number, exampleCandles[:9], 3, 10)
+	if err != bot.ErrCalcMACDNotEnoughInfo {
+		t.Error(err)
+	}
+}
+
+func TestCalculateSignalLinePeriod(t *testing.T) {
+	data := []decimal.Decimal{td(1), td(2), td(3)}
+	got, err := bot.CalculateSignalLinePeriod(data, decimal.Zero, 3)
+	if err != nil {
+		t.Error(err)
+	}
+	checkStringFixed(got, 2, "2.50", t)
+	_, err = bot.CalculateSignalLinePeriod(data, decimal.Zero, 4)
+	if err != bot.ErrCalcSignalNotEnoughInfo {
+		t.Error(err)
+	}
+}
+
+func TestCandlesToATR(t *testing.T) {
+	// The average of each candle's true range, which takes in gaps from the previous close
+	candles := []exchange.Candle{
+		{High: td(105), Low: td(95), Close: td(100)},
+		{High: td(110), Low: td(100), Close: td(108)},
+		{High: td(109), Low: td(90), Close: td(92)},
+	}
+	got, err := bot.CandlesToATR(candles, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	checkStringFixed(got, 2, "14.50", t)
+	_, err = bot.CandlesToATR(candles, 3)
+	if err != bot.ErrCalcATRNotEnoughInfo {
+		t.Error(err)
+	}
+}
+
 func TestCalculateHistogram(t *testing.T) {
 	got := bot.CalculateHistogram(td(1), td(1))
 	checkStringFixed(got, 0, "0", t)
 }
+
+func macdTEMASnapshot(tema int64, histogram int64, position bot.Position) bot.Snapshot {
+	return bot.Snapshot{
+		Symbol:     "BTC-USD",
+		Candles:    exampleCandles,
+		Indicators: bot.Indicators{TEMA: td(tema), Histogram: td(histogram)},
+		Position:   position,
+	}
+}
+
+func TestMACDTEMABuysOnAStrongHistogram(t *testing.T) {
+	strategy := bot.NewMACDTEMA()
+	intents, err := strategy.Decide(macdTEMASnapshot(20030, 7, bot.Position{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].Action != bot.Buy || intents[0].Limit.String() != "20030" {
+		t.Errorf("Got %+v, expected a buy at the last close", intents)
+	}
+
+	intents, _ = strategy.Decide(macdTEMASnapshot(20030, 6, bot.Position{}))
+	if len(intents) != 1 || intents[0].Action != bot.Hold {
+		t.Errorf("Got %+v, expected to hold", intents)
+	}
+}
+
+func TestMACDTEMATrailsAndSells(t *testing.T) {
+	strategy := bot.NewMACDTEMA()
+	holding := bot.Position{Order: exchange.Order{ID: "4c2e1a0e-6b7f-4d1c-9f2a-1e2d3c4b5a69", Limit: td(20000)}, Trail: td(20010)}
+
+	// Rising TEMA drags the trail up behind it
+	intents, _ := strategy.Decide(macdTEMASnapshot(20020, 0, holding))
+	if len(intents) != 1 || intents[0].Action != bot.AdjustStop || intents[0].Stop.String() != "20015" {
+		t.Errorf("Got %+v, expected the stop moved to 20015", intents)
+	}
+
+	// Falling through the trail with enough gain sells
+	holding.Trail = td(20015)
+	intents, _ = strategy.Decide(macdTEMASnapshot(20012, 0, holding))
+	if len(intents) != 1 || intents[0].Action != bot.Sell {
+		t.Errorf("Got %+v, expected a sell", intents)
+	}
+
+	// Falling through the trail without enough gain holds on
+	holding.Trail = td(20009)
+	intents, _ = strategy.Decide(macdTEMASnapshot(20008, 0, holding))
+	if len(intents) != 1 || intents[0].Action != bot.Hold {
+		t.Errorf("Got %+v, expected to hold", intents)
+	}
+
+	// The gain is measured from what the order filled at when the venue says, not its limit
+	holding.Order.FilledQuantity = td(2)
+	holding.Order.Proceeds = td(39990)
+	intents, _ = strategy.Decide(macdTEMASnapshot(20008, 0, holding))
+	if len(intents) != 1 || intents[0].Action != bot.Sell {
+		t.Errorf("Got %+v, expected a sell from a 19995 fill", intents)
+	}
+
+	// Without anything to measure from there is no telling whether it's a gain
+	holding.Order = exchange.Order{ID: "4c2e1a0e-6b7f-4d1c-9f2a-1e2d3c4b5a69"}
+	_, err := strategy.Decide(macdTEMASnapshot(20008, 0, holding))
+	if err == nil {
+		t.Error("Expected an error without an entry price")
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	cases := map[string]string{
+		"6":       "6",
+		"0.5%":    "0.5%",
+		" 1.5ATR": "1.5atr",
+	}
+	for in, expected := range cases {
+		got, err := bot.ParseThreshold(in)
+		if err != nil {
+			t.Errorf("%q: %s", in, err)
+		}
+		if got.String() != expected {
+			t.Errorf("%q came out as %s, expected %s", in, got, expected)
+		}
+	}
+	_, err := bot.ParseThreshold("lots")
+	if !errors.Is(err, bot.ErrInvalidThreshold) {
+		t.Errorf("Got %v, expected an invalid threshold", err)
+	}
+}
+
+func TestThresholdResolve(t *testing.T) {
+	price := td(30000)
+	atr := td(40)
+	got, _ := bot.AbsoluteThreshold(td(6)).Resolve(price, atr)
+	checkStringFixed(got, 2, "6.00", t)
+	got, _ = bot.PercentThreshold(decimal.NewFromFloat(0.5)).Resolve(price, atr)
+	checkStringFixed(got, 2, "150.00", t)
+	got, _ = bot.ATRThreshold(decimal.NewFromFloat(1.5)).Resolve(price, atr)
+	checkStringFixed(got, 2, "60.00", t)
+	// No ATR yet
+	_, err := bot.ATRThreshold(td(1)).Resolve(price, decimal.Zero)
+	if !errors.Is(err, bot.ErrCalcATRNotEnoughInfo) {
+		t.Errorf("Got %v, expected not enough info for an ATR", err)
+	}
+}
+
+func TestMACDTEMAWithRelativeThresholds(t *testing.T) {
+	// 0.03% of the 20030 close is 6.009
+	strategy := &bot.MACDTEMA{
+		BuyHistogram: bot.PercentThreshold(decimal.NewFromFloat(0.03)),
+		SellGain:     bot.ATRThreshold(td(10)),
+		TrailLag:     bot.ATRThreshold(td(5)),
+	}
+	intents, err := strategy.Decide(macdTEMASnapshot(20030, 6, bot.Position{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].Action != bot.Hold {
+		t.Errorf("Got %+v, expected to hold", intents)
+	}
+	intents, _ = strategy.Decide(macdTEMASnapshot(20030, 7, bot.Position{}))
+	if len(intents) != 1 || intents[0].Action != bot.Buy {
+		t.Errorf("Got %+v, expected a buy", intents)
+	}
+
+	// With an ATR of 2 the trail lags by 10
+	holding := bot.Position{Order: exchange.Order{ID: "1", Limit: td(20000)}, Trail: td(20010)}
+	snapshot := macdTEMASnapshot(20020, 0, holding)
+	snapshot.Indicators.ATR = td(2)
+	intents, _ = strategy.Decide(snapshot)
+	if len(intents) != 1 || intents[0].Action != bot.AdjustStop || intents[0].Stop.String() != "20010" {
+		t.Errorf("Got %+v, expected the stop moved to 20010", intents)
+	}
+	// Without an ATR there's nothing to work the thresholds out from yet, so it waits
+	intents, err = strategy.Decide(macdTEMASnapshot(20020, 0, holding))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].Action != bot.Hold {
+		t.Errorf("Got %+v, expected to hold", intents)
+	}
+}
+
+func TestNewBotRejectsWhatItCantTrade(t *testing.T) {
+	_, err := bot.NewBot(context.Background(), bot.Modes["Paper"], "BTC-USD", nil, bot.NewMACDTEMA(), bot.WithInterval("3min"))
+	if !errors.Is(err, exchange.ErrUnsupported) {
+		t.Errorf("Got %v, expected the interval to be unsupported", err)
+	}
+	_, err = bot.NewBot(context.Background(), bot.Modes["Paper"], "BTC-USD", nil, bot.NewMACDTEMA(), bot.WithPeriods(bot.Periods{MACDFast: 26, MACDSlow: 12}))
+	if !errors.Is(err, bot.ErrInvalidPeriods) {
+		t.Errorf("Got %v, expected the periods to be invalid", err)
+	}
+}
+
+func TestBacktestTradesAtTheClose(t *testing.T) {
+	dir := t.TempDir()
+	store, err := candlestore.Open(filepath.Join(dir, "candles"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A flat day of DOGE-USD to start from, then an hour that climbs a tenth of a cent a minute for half an
+	// hour and falls back
+	start := time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)
+	interval := bittrex.CandleIntervals[exchange.Interval1Min]
+	for _, day := range []time.Time{start.Add(-time.Hour * 24), start} {
+		candles := make([]bittrex.CandleResponse, 0, 24*60)
+		for i := 0; i < 24*60; i++ {
+			at := day.Add(time.Duration(i) * time.Minute)
+			steps := int64(0)
+			if minute := int64(at.Sub(start) / time.Minute); minute >= 0 && minute < 30 {
+				steps = minute
+			} else if minute >= 30 && minute < 60 {
+				steps = 60 - minute
+			}
+			rate := decimal.New(5000+10*steps, -5)
+			candles = append(candles, bittrex.CandleResponse{StartsAt: at, Open: rate, High: rate, Low: rate, Close: rate, Volume: td(1000), QuoteVolume: rate.Mul(td(1000))})
+		}
+		err = store.Put("DOGE-USD", interval, day, candles)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	scenario := fmt.Sprintf(`{"Symbols": ["DOGE-USD"], "Intervals": [%q], "Data": "candles", "Start": %q, "End": %q, "Tick": "1m"}`,
+		interval, start.Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339))
+	err = ioutil.WriteFile(filepath.Join(dir, "scenario.json"), []byte(scenario), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, "MOCK_SCENARIO", filepath.Join(dir, "scenario.json"))
+	setenv(t, "CANDLE_OFFLINE", "1")
+
+	strategy := &bot.MACDTEMA{
+		BuyHistogram: bot.AbsoluteThreshold(decimal.Zero),
+		SellGain:     bot.AbsoluteThreshold(decimal.Zero),
+		TrailLag:     bot.AbsoluteThreshold(decimal.New(1, -5)),
+	}
+	ctx := context.Background()
+	trader, err := bot.NewBot(ctx, bot.Modes["Testing"], "DOGE-USD", nil, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = trader.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The strategy buys and sells at the last close, which only trades if the mock's book meets it there
+	filled := map[string]int{}
+	for _, order := range trader.State().OrderHistory {
+		if !order.FilledQuantity.IsPositive() {
+			t.Errorf("Recorded an order that never filled: %+v", order)
+		}
+		filled[order.Side]++
+	}
+	if filled[exchange.Buy] == 0 || filled[exchange.Sell] == 0 {
+		t.Errorf("Filled %d buys and %d sells, expected at least one of each", filled[exchange.Buy], filled[exchange.Sell])
+	}
+}
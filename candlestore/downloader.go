@@ -0,0 +1,80 @@
+package candlestore
+
+import (
+	"context"
+	"time"
+
+	"cryptofu/bittrex"
+)
+
+// Source is anywhere historical candles can be downloaded from, like a *bittrex.Client
+type Source interface {
+	GetHistoricalCandles(ctx context.Context, symbol string, interval string, year int, month int, day int) ([]bittrex.CandleResponse, error)
+}
+
+// Downloader fills a store from a source, only asking for the buckets the store does not hold yet. A
+// downloader without a source never goes online and fails with ErrMissing instead.
+type Downloader struct {
+	store  *Store
+	source Source
+	now    func() time.Time
+}
+
+// NewDownloader fills store from source. Pass a nil source to stay offline.
+func NewDownloader(store *Store, source Source) *Downloader {
+	return &Downloader{store: store, source: source, now: time.Now}
+}
+
+// Fill downloads every bucket of [from, to) the store is missing and says how many it saved. Buckets that
+// have not finished yet are left out, so running it again later picks up where it stopped.
+func (d *Downloader) Fill(ctx context.Context, symbol string, interval string, from time.Time, to time.Time) (int, error) {
+	missing, err := d.store.Missing(symbol, interval, from, to)
+	if err != nil {
+		return 0, err
+	}
+	saved := 0
+	for _, bucket := range missing {
+		_, end, _ := bittrex.HistoricalBucket(interval, bucket)
+		if end.After(d.now()) {
+			break
+		}
+		_, err := d.download(ctx, symbol, interval, bucket)
+		if err != nil {
+			return saved, err
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// GetHistoricalCandles serves a bucket from the store, downloading and saving it first if it isn't there. It
+// lets a downloader stand in for the client wherever historical candles are read.
+func (d *Downloader) GetHistoricalCandles(ctx context.Context, symbol string, interval string, year int, month int, day int) ([]bittrex.CandleResponse, error) {
+	bucket := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if d.store.Has(symbol, interval, bucket) {
+		return d.store.Get(symbol, interval, bucket)
+	}
+	return d.download(ctx, symbol, interval, bucket)
+}
+
+func (d *Downloader) download(ctx context.Context, symbol string, interval string, bucket time.Time) ([]bittrex.CandleResponse, error) {
+	if d.source == nil {
+		return d.store.Get(symbol, interval, bucket)
+	}
+	candles, err := d.source.GetHistoricalCandles(ctx, symbol, interval, bucket.Year(), int(bucket.Month()), bucket.Day())
+	if err != nil {
+		return make([]bittrex.CandleResponse, 0), err
+	}
+	_, end, err := bittrex.HistoricalBucket(interval, bucket)
+	if err != nil {
+		return make([]bittrex.CandleResponse, 0), err
+	}
+	// A bucket still filling up would look complete on disk, so only finished ones are kept
+	if !end.After(d.now()) {
+		err = d.store.Put(symbol, interval, bucket, candles)
+		if err != nil {
+			return make([]bittrex.CandleResponse, 0), err
+		}
+	}
+	return candles, nil
+}
@@ -0,0 +1,264 @@
+// Package candlestore keeps historical candles on local disk so backtests and the mock server can run offline.
+//
+// Candles are saved as gzipped JSON lines, one file per historical bucket bittrex serves them in:
+//
+//	<dir>/<symbol>/<interval>/2020-06-03.jsonl.gz (MINUTE_1, MINUTE_5)
+//	<dir>/<symbol>/<interval>/2020-06.jsonl.gz    (HOUR_1)
+//	<dir>/<symbol>/<interval>/2020.jsonl.gz       (DAY_1)
+//
+// A bucket file only exists once the whole bucket has been saved, so its presence is how the store knows
+// which ranges it holds.
+package candlestore
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cryptofu/bittrex"
+)
+
+const fileExtension = ".jsonl.gz"
+
+var (
+	// ErrMissing means the store does not hold every bucket a range needs
+	ErrMissing = errors.New("candles are not in the store")
+)
+
+// Store is a directory of saved candles
+type Store struct {
+	dir string
+}
+
+// Range is a span of time [From, To)
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// Open uses dir as a candle store, creating it if it isn't there
+func Open(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir is where the store keeps its files
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Has is whether the store holds the bucket t falls in
+func (s *Store) Has(symbol string, interval string, t time.Time) bool {
+	name, err := s.bucketFile(symbol, interval, t)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(name)
+	return err == nil
+}
+
+// Put saves every candle of the bucket t falls in, replacing whatever was saved for it before
+func (s *Store) Put(symbol string, interval string, t time.Time, candles []bittrex.CandleResponse) error {
+	name, err := s.bucketFile(symbol, interval, t)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+	// Write somewhere else first so a half written bucket never looks like a saved one
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".partial-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	zipped := gzip.NewWriter(tmp)
+	encoder := json.NewEncoder(zipped)
+	for _, candle := range candles {
+		err = encoder.Encode(candle)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	err = zipped.Close()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	// Temporary files are only readable by us, and the rename keeps that
+	err = tmp.Chmod(0644)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get reads back the saved candles of the bucket t falls in
+func (s *Store) Get(symbol string, interval string, t time.Time) ([]bittrex.CandleResponse, error) {
+	ret := make([]bittrex.CandleResponse, 0)
+	name, err := s.bucketFile(symbol, interval, t)
+	if err != nil {
+		return ret, err
+	}
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return ret, fmt.Errorf("%s %s at %s: %w", symbol, interval, t.UTC().Format(time.RFC3339), ErrMissing)
+	}
+	if err != nil {
+		return ret, err
+	}
+	defer file.Close()
+
+	zipped, err := gzip.NewReader(file)
+	if err != nil {
+		return ret, fmt.Errorf("reading %s: %w", name, err)
+	}
+	defer zipped.Close()
+	scanner := bufio.NewScanner(zipped)
+	for scanner.Scan() {
+		var candle bittrex.CandleResponse
+		err = json.Unmarshal(scanner.Bytes(), &candle)
+		if err != nil {
+			return make([]bittrex.CandleResponse, 0), fmt.Errorf("reading %s: %w", name, err)
+		}
+		ret = append(ret, candle)
+	}
+	return ret, scanner.Err()
+}
+
+// Load reads every saved candle starting in [from, to). It fails with ErrMissing unless the store holds the
+// whole range.
+func (s *Store) Load(symbol string, interval string, from time.Time, to time.Time) ([]bittrex.CandleResponse, error) {
+	ret := make([]bittrex.CandleResponse, 0)
+	buckets, err := Buckets(interval, from, to)
+	if err != nil {
+		return ret, err
+	}
+	for _, bucket := range buckets {
+		candles, err := s.Get(symbol, interval, bucket)
+		if err != nil {
+			return make([]bittrex.CandleResponse, 0), err
+		}
+		for _, candle := range candles {
+			if !candle.StartsAt.Before(from) && candle.StartsAt.Before(to) {
+				ret = append(ret, candle)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].StartsAt.Before(ret[j].StartsAt)
+	})
+	return ret, nil
+}
+
+// Missing is the start of every bucket in [from, to) the store does not hold
+func (s *Store) Missing(symbol string, interval string, from time.Time, to time.Time) ([]time.Time, error) {
+	ret := make([]time.Time, 0)
+	buckets, err := Buckets(interval, from, to)
+	if err != nil {
+		return ret, err
+	}
+	for _, bucket := range buckets {
+		if !s.Has(symbol, interval, bucket) {
+			ret = append(ret, bucket)
+		}
+	}
+	return ret, nil
+}
+
+// Ranges is what the store holds for a symbol and interval, with neighbouring buckets merged together
+func (s *Store) Ranges(symbol string, interval string) ([]Range, error) {
+	ret := make([]Range, 0)
+	layout, err := bucketLayout(interval)
+	if err != nil {
+		return ret, err
+	}
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, symbol, interval))
+	if os.IsNotExist(err) {
+		return ret, nil
+	}
+	if err != nil {
+		return ret, err
+	}
+
+	starts := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), fileExtension) {
+			continue
+		}
+		start, err := time.Parse(layout, strings.TrimSuffix(file.Name(), fileExtension))
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	for _, start := range starts {
+		_, end, _ := bittrex.HistoricalBucket(interval, start)
+		if len(ret) > 0 && ret[len(ret)-1].To.Equal(start) {
+			ret[len(ret)-1].To = end
+			continue
+		}
+		ret = append(ret, Range{From: start, To: end})
+	}
+	return ret, nil
+}
+
+// Buckets is the start of every historical bucket that overlaps [from, to)
+func Buckets(interval string, from time.Time, to time.Time) ([]time.Time, error) {
+	ret := make([]time.Time, 0)
+	bucket, _, err := bittrex.HistoricalBucket(interval, from)
+	if err != nil {
+		return ret, err
+	}
+	for bucket.Before(to) {
+		ret = append(ret, bucket)
+		_, bucket, _ = bittrex.HistoricalBucket(interval, bucket)
+	}
+	return ret, nil
+}
+
+func (s *Store) bucketFile(symbol string, interval string, t time.Time) (string, error) {
+	layout, err := bucketLayout(interval)
+	if err != nil {
+		return "", err
+	}
+	start, _, err := bittrex.HistoricalBucket(interval, t)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, symbol, interval, start.Format(layout)+fileExtension), nil
+}
+
+func bucketLayout(interval string) (string, error) {
+	switch interval {
+	case bittrex.CandleIntervals["1min"], bittrex.CandleIntervals["5min"]:
+		return "2006-01-02", nil
+	case bittrex.CandleIntervals["1hour"]:
+		return "2006-01", nil
+	case bittrex.CandleIntervals["1day"]:
+		return "2006", nil
+	}
+	return "", fmt.Errorf("unknown candle interval %q", interval)
+}
@@ -0,0 +1,167 @@
+package candlestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"cryptofu/bittrex"
+
+	"github.com/shopspring/decimal"
+)
+
+var minute = bittrex.CandleIntervals["1min"]
+
+// fakeSource serves one candle an hour for any day and counts how many days it was asked for
+type fakeSource struct {
+	requests int
+}
+
+func (f *fakeSource) GetHistoricalCandles(ctx context.Context, symbol string, interval string, year int, month int, day int) ([]bittrex.CandleResponse, error) {
+	f.requests++
+	start := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	ret := make([]bittrex.CandleResponse, 0, 24)
+	for i := 0; i < 24; i++ {
+		ret = append(ret, bittrex.CandleResponse{
+			StartsAt: start.Add(time.Duration(i) * time.Hour),
+			Close:    decimal.RequireFromString(fmt.Sprintf("0.05%02d", i)),
+		})
+	}
+	return ret, nil
+}
+
+func TestPutAndLoad(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2020, 6, 3, 0, 0, 0, 0, time.UTC)
+	candles, _ := (&fakeSource{}).GetHistoricalCandles(context.Background(), "DOGE-USD", minute, 2020, 6, 3)
+
+	if store.Has("DOGE-USD", minute, day) {
+		t.Fatal("Empty store says it has a day")
+	}
+	err = store.Put("DOGE-USD", minute, day.Add(time.Hour), candles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.Has("DOGE-USD", minute, day.Add(23*time.Hour)) {
+		t.Fatal("Store lost the day it was given")
+	}
+	name, _ := store.bucketFile("DOGE-USD", minute, day)
+	if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Bucket file was %v, %v, expected it readable like the rest of the store", info, err)
+	}
+
+	loaded, err := store.Load("DOGE-USD", minute, day.Add(6*time.Hour), day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 6 {
+		t.Fatalf("Loaded %d candles, expected 6", len(loaded))
+	}
+	if !loaded[0].StartsAt.Equal(day.Add(6*time.Hour)) || loaded[0].Close.String() != "0.0506" {
+		t.Errorf("First candle was %+v", loaded[0])
+	}
+
+	_, err = store.Load("DOGE-USD", minute, day, day.AddDate(0, 0, 2))
+	if !errors.Is(err, ErrMissing) {
+		t.Errorf("Expected ErrMissing for a range the store half holds, got %v", err)
+	}
+}
+
+func TestRangesMergeNeighbours(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	june := func(day int) time.Time {
+		return time.Date(2020, 6, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, day := range []int{1, 2, 3, 5} {
+		err = store.Put("DOGE-USD", minute, june(day), []bittrex.CandleResponse{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ranges, err := store.Ranges("DOGE-USD", minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Range{{june(1), june(4)}, {june(5), june(6)}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Got ranges %v, expected %v", ranges, expected)
+	}
+	for i := range expected {
+		if !ranges[i].From.Equal(expected[i].From) || !ranges[i].To.Equal(expected[i].To) {
+			t.Errorf("Range %d was %v, expected %v", i, ranges[i], expected[i])
+		}
+	}
+
+	missing, err := store.Missing("DOGE-USD", minute, june(1), june(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 2 || !missing[0].Equal(june(4)) || !missing[1].Equal(june(6)) {
+		t.Errorf("Missing was %v", missing)
+	}
+}
+
+func TestFillOnlyDownloadsMissingDays(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &fakeSource{}
+	downloader := NewDownloader(store, source)
+	downloader.now = func() time.Time {
+		return time.Date(2020, 6, 5, 12, 0, 0, 0, time.UTC)
+	}
+	from := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	saved, err := downloader.Fill(context.Background(), "DOGE-USD", minute, from, from.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != 3 || source.requests != 3 {
+		t.Fatalf("Saved %d days with %d requests, expected 3 and 3", saved, source.requests)
+	}
+
+	// The 5th is still going, so only the 4th is new
+	saved, err = downloader.Fill(context.Background(), "DOGE-USD", minute, from, from.AddDate(0, 0, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != 1 || source.requests != 4 {
+		t.Errorf("Saved %d days with %d requests, expected 1 and 4", saved, source.requests)
+	}
+}
+
+func TestOfflineDownloaderServesFromStore(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	online := NewDownloader(store, &fakeSource{})
+	_, err = online.GetHistoricalCandles(context.Background(), "DOGE-USD", minute, 2020, 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline := NewDownloader(store, nil)
+	candles, err := offline.GetHistoricalCandles(context.Background(), "DOGE-USD", minute, 2020, 6, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 24 {
+		t.Errorf("Got %d candles from the store, expected 24", len(candles))
+	}
+	_, err = offline.GetHistoricalCandles(context.Background(), "DOGE-USD", minute, 2020, 6, 4)
+	if !errors.Is(err, ErrMissing) {
+		t.Errorf("Expected an offline miss to be ErrMissing, got %v", err)
+	}
+}
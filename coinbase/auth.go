@@ -0,0 +1,138 @@
+package coinbase
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// jwtLifetime is how long a signed token is good for, coinbase won't take more than two minutes
+	jwtLifetime = time.Minute * 2
+)
+
+// Signer authenticates a request. body is the exact bytes that get sent, nil for requests without one.
+type Signer interface {
+	Sign(req *http.Request, body []byte, now time.Time) error
+}
+
+// HMACSigner signs requests with a legacy api key and secret
+// https://docs.cloud.coinbase.com/advanced-trade-api/docs/rest-api-auth#legacy-api-keys
+type HMACSigner struct {
+	apiKey    string
+	secretKey string
+}
+
+// NewHMACSigner signs with a legacy api key and secret
+func NewHMACSigner(apiKey string, secretKey string) *HMACSigner {
+	return &HMACSigner{apiKey: apiKey, secretKey: secretKey}
+}
+
+// Sign adds the CB-ACCESS headers. The signature covers the timestamp, method, path without its query, and body.
+func (s *HMACSigner) Sign(req *http.Request, body []byte, now time.Time) error {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("CB-ACCESS-KEY", s.apiKey)
+	req.Header.Set("CB-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("CB-ACCESS-SIGN", makeHMACSignature(s.secretKey, timestamp, req.Method, req.URL.Path, body))
+	return nil
+}
+
+func makeHMACSignature(secretKey string, timestamp string, method string, path string, body []byte) string {
+	hasher := hmac.New(sha256.New, []byte(secretKey))
+	hasher.Write([]byte(timestamp + method + path))
+	hasher.Write(body)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// JWTSigner signs requests with a cloud api key, a short lived ES256 token for every request
+// https://docs.cloud.coinbase.com/advanced-trade-api/docs/rest-api-auth
+type JWTSigner struct {
+	keyName    string
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewJWTSigner signs as the named key. privateKeyPEM is the EC private key coinbase handed out with it.
+func NewJWTSigner(keyName string, privateKeyPEM string) (*JWTSigner, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("coinbase private key isn't PEM encoded")
+	}
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		parsed, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if pkcs8Err != nil {
+			return nil, fmt.Errorf("coinbase private key: %w", err)
+		}
+		var ok bool
+		privateKey, ok = parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("coinbase private key isn't an EC key")
+		}
+	}
+	return &JWTSigner{keyName: keyName, privateKey: privateKey}, nil
+}
+
+// Sign adds a bearer token scoped to the request's method, host and path
+func (s *JWTSigner) Sign(req *http.Request, body []byte, now time.Time) error {
+	token, err := s.token(req.Method+" "+req.URL.Host+req.URL.Path, now)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (s *JWTSigner) token(uri string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]string{
+		"alg":   "ES256",
+		"typ":   "JWT",
+		"kid":   s.keyName,
+		"nonce": hex.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"sub": s.keyName,
+		"iss": "cdp",
+		"nbf": now.Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"uri": uri,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// ES256 signatures are r and s as fixed width 32 byte numbers, one after the other
+	signature := append(fixedWidth(r, 32), fixedWidth(sig, 32)...)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func fixedWidth(n *big.Int, size int) []byte {
+	ret := make([]byte, size)
+	b := n.Bytes()
+	copy(ret[size-len(b):], b)
+	return ret
+}
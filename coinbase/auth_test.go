@@ -0,0 +1,101 @@
+package coinbase
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSignature(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.coinbase.com/api/v3/brokerage/orders?ignored=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"product_id":"DOGE-USD"}`)
+	err = NewHMACSigner("key", "secret").Sign(req, body, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// HMAC-SHA256 of 1700000000POST/api/v3/brokerage/orders{"product_id":"DOGE-USD"}, the query isn't signed
+	if req.Header.Get("CB-ACCESS-SIGN") != "bfd5f55cddd50852a5f9d71b6f027e9c7267403a7062fb72eaad93d47510c214" {
+		t.Errorf("Signature was %s", req.Header.Get("CB-ACCESS-SIGN"))
+	}
+	if req.Header.Get("CB-ACCESS-KEY") != "key" || req.Header.Get("CB-ACCESS-TIMESTAMP") != "1700000000" {
+		t.Errorf("Headers were %v", req.Header)
+	}
+}
+
+func TestJWTSignature(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	signer, err := NewJWTSigner("organizations/org/apiKeys/key", keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.coinbase.com/api/v3/brokerage/accounts?limit=250", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	err = signer.Sign(req, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("Token %q doesn't have three parts", token)
+	}
+
+	var claims struct {
+		Sub string
+		Iss string
+		Nbf int64
+		Exp int64
+		URI string
+	}
+	content, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(content, &claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.URI != "GET api.coinbase.com/api/v3/brokerage/accounts" || claims.Sub != "organizations/org/apiKeys/key" || claims.Nbf != now.Unix() || claims.Exp != now.Add(jwtLifetime).Unix() {
+		t.Errorf("Claims were %+v", claims)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("Signature was %d bytes, %v", len(signature), err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&privateKey.PublicKey, digest[:], r, s) {
+		t.Error("Token signature doesn't verify")
+	}
+
+	_, err = NewJWTSigner("key", "not a key")
+	if err == nil {
+		t.Error("Expected a key that isn't PEM to fail")
+	}
+}
@@ -0,0 +1,202 @@
+// Package coinbase trades on Coinbase Advanced Trade through the exchange.Exchange interface.
+//
+// Coinbase already writes products BASE-QUOTE, so symbols mostly pass straight through. Requests are signed
+// with either a legacy HMAC key or a cloud key's JWT, and long lists come back a page at a time behind a cursor.
+package coinbase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cryptofu/ratelimit"
+)
+
+const (
+	// DefaultBaseURL is the live coinbase api
+	DefaultBaseURL = "https://api.coinbase.com"
+	// brokeragePath is where the advanced trade endpoints live
+	brokeragePath = "/api/v3/brokerage"
+	// pageSize is how many results to ask for at once from the endpoints that page
+	pageSize = 250
+)
+
+// Client talks to the coinbase advanced trade api. A client is safe to share between bots.
+type Client struct {
+	baseURL    string
+	signer     Signer
+	httpClient *http.Client
+	now        func() time.Time
+	limiter    *ratelimit.Limiter
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// NewClient makes a new coinbase client. Without options it points at the live api with no credentials, so
+// only public endpoints work.
+func NewClient(options ...Option) *Client {
+	client := &Client{
+		baseURL: DefaultBaseURL,
+		httpClient: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		now: time.Now,
+		// Coinbase allows 30 private calls a second, public ones are stricter
+		limiter: ratelimit.New(10, 30),
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// WithBaseURL points the client at a different api, like a stub server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithSigner sets how authenticated calls are signed, see NewHMACSigner and NewJWTSigner
+func WithSigner(signer Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// WithHTTPClient swaps out the http client used to make requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithClock swaps out the clock used to sign requests and tell which candles have closed
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// WithRateLimiter swaps out the rate limiter. Pass the same limiter to several clients to share it, or nil to turn limiting off.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// BaseURL is the api the client is pointed at
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// call sends a request to a brokerage endpoint and decodes the json answer into out. body is sent as json
+// when it isn't nil.
+func (c *Client) call(ctx context.Context, method string, path string, params url.Values, body interface{}, out interface{}) error {
+	resp, err := c.send(ctx, method, path, params, body)
+	if err != nil {
+		return err
+	}
+	return decode(resp, out)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, params url.Values, body interface{}) (*http.Response, error) {
+	var marshaledBody []byte
+	if body != nil {
+		var err error
+		marshaledBody, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.limiter != nil {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := c.baseURL + brokeragePath + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(marshaledBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "cryptofu")
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.signer != nil {
+		err = c.signer.Sign(req, marshaledBody, c.now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// pages calls a cursor paged endpoint until it runs out, handing each page to next. next decodes the page
+// and says whether there is another and where it starts.
+func (c *Client) pages(ctx context.Context, path string, params url.Values, next func(content []byte) (bool, string, error)) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("limit", fmt.Sprint(pageSize))
+	for {
+		var page json.RawMessage
+		err := c.call(ctx, http.MethodGet, path, params, nil, &page)
+		if err != nil {
+			return err
+		}
+		more, cursor, err := next(page)
+		if err != nil {
+			return err
+		}
+		if !more || cursor == "" {
+			return nil
+		}
+		params.Set("cursor", cursor)
+	}
+}
+
+// decode reads a successful response into out. It closes the response body.
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, out)
+}
+
+// newClientOrderID makes a random v4 uuid
+func newClientOrderID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
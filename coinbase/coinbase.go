@@ -0,0 +1,489 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cryptofu/exchange"
+)
+
+var _ exchange.Exchange = (*Client)(nil)
+
+// granularity is coinbase's name for a candle interval, and how long it is
+type granularity struct {
+	Name     string
+	Duration time.Duration
+}
+
+var (
+	// Granularities is coinbase's candle granularity for each of the bittrex.CandleIntervals keys
+	Granularities = map[string]granularity{
+		exchange.Interval1Min:  {"ONE_MINUTE", time.Minute},
+		exchange.Interval5Min:  {"FIVE_MINUTE", time.Minute * 5},
+		exchange.Interval1Hour: {"ONE_HOUR", time.Hour},
+		exchange.Interval1Day:  {"ONE_DAY", time.Hour * 24},
+	}
+	// timesInForce are coinbase's names for ours
+	timesInForce = map[string]string{
+		exchange.GoodTilCancelled:  "GOOD_UNTIL_CANCELLED",
+		exchange.ImmediateOrCancel: "IMMEDIATE_OR_CANCEL",
+		exchange.FillOrKill:        "FILL_OR_KILL",
+	}
+	// openStatuses are the order statuses that can still fill, everything else is closed
+	openStatuses = map[string]bool{
+		"OPEN":    true,
+		"PENDING": true,
+		"QUEUED":  true,
+	}
+)
+
+const (
+	// recentCandles is how many candles Candles asks for, coinbase answers at most 350 at once
+	recentCandles = 300
+)
+
+// NativeSymbol is coinbase's product id for a BASE-QUOTE symbol. Coinbase writes them the same way, so this
+// only checks and uppercases it.
+func NativeSymbol(symbol string) (string, error) {
+	parts := strings.Split(symbol, "-")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("%w: %q isn't a BASE-QUOTE symbol", exchange.ErrNotFound, symbol)
+	}
+	return strings.ToUpper(symbol), nil
+}
+
+// Name is coinbase
+func (c *Client) Name() string {
+	return "coinbase"
+}
+
+// Ping checks coinbase is up
+func (c *Client) Ping(ctx context.Context) error {
+	var ret struct {
+		Iso string `json:"iso"`
+	}
+	return c.call(ctx, http.MethodGet, "/time", nil, nil, &ret)
+}
+
+// GetKeyPermissions gets what the signing key may do
+func (c *Client) GetKeyPermissions(ctx context.Context) (KeyPermissionsResponse, error) {
+	var ret KeyPermissionsResponse
+	err := c.call(ctx, http.MethodGet, "/key_permissions", nil, nil, &ret)
+	return ret, err
+}
+
+// Account is the portfolio the signing key trades for
+func (c *Client) Account(ctx context.Context) (exchange.Account, error) {
+	permissions, err := c.GetKeyPermissions(ctx)
+	if err != nil {
+		return exchange.Account{}, err
+	}
+	return exchange.Account{ID: permissions.PortfolioUUID}, nil
+}
+
+// GetAccounts gets every account, a page at a time
+func (c *Client) GetAccounts(ctx context.Context) ([]AccountResponse, error) {
+	ret := make([]AccountResponse, 0)
+	err := c.pages(ctx, "/accounts", nil, func(content []byte) (bool, string, error) {
+		var page AccountsResponse
+		err := json.Unmarshal(content, &page)
+		ret = append(ret, page.Accounts...)
+		return page.HasNext, page.Cursor, err
+	})
+	return ret, err
+}
+
+// Balances is everything the account holds
+func (c *Client) Balances(ctx context.Context) ([]exchange.Balance, error) {
+	ret := make([]exchange.Balance, 0)
+	accounts, err := c.GetAccounts(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, account := range accounts {
+		available := toDecimal(account.AvailableBalance.Value)
+		ret = append(ret, exchange.Balance{
+			Currency:  account.Currency,
+			Total:     available.Add(toDecimal(account.Hold.Value)),
+			Available: available,
+		})
+	}
+	return ret, nil
+}
+
+// GetProducts gets every spot product
+func (c *Client) GetProducts(ctx context.Context) ([]ProductResponse, error) {
+	var ret ProductsResponse
+	err := c.call(ctx, http.MethodGet, "/products", url.Values{"product_type": {"SPOT"}}, nil, &ret)
+	return ret.Products, err
+}
+
+// GetProduct gets one product
+func (c *Client) GetProduct(ctx context.Context, productID string) (ProductResponse, error) {
+	var ret ProductResponse
+	err := c.call(ctx, http.MethodGet, "/products/"+productID, nil, nil, &ret)
+	return ret, err
+}
+
+// Markets is every market coinbase lists
+func (c *Client) Markets(ctx context.Context) ([]exchange.MarketInfo, error) {
+	ret := make([]exchange.MarketInfo, 0)
+	products, err := c.GetProducts(ctx)
+	if err != nil {
+		return ret, err
+	}
+	for _, product := range products {
+		ret = append(ret, toMarketInfo(product))
+	}
+	return ret, nil
+}
+
+// Market is one market's trading rules
+func (c *Client) Market(ctx context.Context, symbol string) (exchange.MarketInfo, error) {
+	productID, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	product, err := c.GetProduct(ctx, productID)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	return toMarketInfo(product), nil
+}
+
+// Ticker is a market's latest prices
+func (c *Client) Ticker(ctx context.Context, symbol string) (exchange.Ticker, error) {
+	productID, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	var trades MarketTradesResponse
+	err = c.call(ctx, http.MethodGet, "/products/"+productID+"/ticker", url.Values{"limit": {"1"}}, nil, &trades)
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	ticker := exchange.Ticker{Symbol: productID, Bid: toDecimal(trades.BestBid), Ask: toDecimal(trades.BestAsk)}
+	if len(trades.Trades) > 0 {
+		ticker.Last = toDecimal(trades.Trades[0].Price)
+	}
+	return ticker, nil
+}
+
+// GetCandles gets a product's candles starting in [start, end), newest first the way coinbase sends them
+func (c *Client) GetCandles(ctx context.Context, productID string, granularity string, start time.Time, end time.Time) ([]CandleResponse, error) {
+	params := url.Values{
+		"start":       {strconv.FormatInt(start.Unix(), 10)},
+		"end":         {strconv.FormatInt(end.Unix(), 10)},
+		"granularity": {granularity},
+	}
+	var ret CandlesResponse
+	err := c.call(ctx, http.MethodGet, "/products/"+productID+"/candles", params, nil, &ret)
+	return ret.Candles, err
+}
+
+// Candles are a market's recent closed candles, oldest first
+func (c *Client) Candles(ctx context.Context, symbol string, interval string) ([]exchange.Candle, error) {
+	ret := make([]exchange.Candle, 0)
+	productID, err := NativeSymbol(symbol)
+	if err != nil {
+		return ret, err
+	}
+	g, ok := Granularities[interval]
+	if !ok {
+		return ret, fmt.Errorf("%w: coinbase has no %s candles", exchange.ErrUnsupported, interval)
+	}
+	now := c.now()
+	candles, err := c.GetCandles(ctx, productID, g.Name, now.Add(-g.Duration*recentCandles), now)
+	if err != nil {
+		return ret, err
+	}
+	for _, candle := range candles {
+		seconds, err := strconv.ParseInt(candle.Start, 10, 64)
+		if err != nil {
+			return ret, fmt.Errorf("candle start %q: %w", candle.Start, err)
+		}
+		startsAt := time.Unix(seconds, 0).UTC()
+		// The newest candle is still trading, bittrex would leave it out
+		if startsAt.Add(g.Duration).After(now) {
+			continue
+		}
+		ret = append(ret, exchange.Candle{
+			StartsAt: startsAt,
+			Open:     toDecimal(candle.Open),
+			High:     toDecimal(candle.High),
+			Low:      toDecimal(candle.Low),
+			Close:    toDecimal(candle.Close),
+			Volume:   toDecimal(candle.Volume),
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].StartsAt.Before(ret[j].StartsAt)
+	})
+	return ret, nil
+}
+
+// PlaceOrder sends an order to coinbase, then looks it up to see how it did. Orders are never retried, the
+// client order id is how a lost one gets found instead.
+func (c *Client) PlaceOrder(ctx context.Context, order exchange.OrderRequest) (exchange.Order, error) {
+	request, err := createOrderRequest(order)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	if request.ClientOrderID == "" {
+		request.ClientOrderID, err = newClientOrderID()
+		if err != nil {
+			return exchange.Order{}, err
+		}
+		order.ClientOrderID = request.ClientOrderID
+	}
+
+	var sent, answered int32
+	tracedCtx := httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			atomic.StoreInt32(&sent, 1)
+		},
+		GotFirstResponseByte: func() {
+			atomic.StoreInt32(&answered, 1)
+		},
+	})
+	resp, err := c.send(tracedCtx, http.MethodPost, "/orders", nil, request)
+	if err != nil {
+		if atomic.LoadInt32(&sent) == 1 && atomic.LoadInt32(&answered) == 0 {
+			return exchange.Order{}, &exchange.UncertainOrderError{Order: order, Err: err}
+		}
+		return exchange.Order{}, err
+	}
+
+	var created CreateOrderResponse
+	err = decode(resp, &created)
+	if err != nil {
+		return exchange.Order{}, &exchange.UncertainOrderError{Order: order, Err: err}
+	}
+	if !created.Success {
+		return exchange.Order{}, createOrderError(created, resp)
+	}
+
+	placed, err := c.GetOrder(ctx, order.Symbol, created.SuccessResponse.OrderID)
+	if err != nil {
+		// The order is in, it just hasn't said how it did yet. Open sends callers back for another look.
+		return exchange.Order{
+			ID:            created.SuccessResponse.OrderID,
+			ClientOrderID: request.ClientOrderID,
+			Symbol:        request.ProductID,
+			Side:          order.Side,
+			Type:          order.Type,
+			TimeInForce:   order.TimeInForce,
+			Quantity:      order.Quantity,
+			Limit:         order.Limit,
+			Status:        exchange.Open,
+			CreatedAt:     c.now(),
+			UpdatedAt:     c.now(),
+		}, nil
+	}
+	return placed, nil
+}
+
+// GetOrder looks an order up by its coinbase id. Coinbase ids are unique across products, so symbol isn't needed.
+func (c *Client) GetOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	var ret GetOrderResponse
+	err := c.call(ctx, http.MethodGet, "/orders/historical/"+id, nil, nil, &ret)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	return toOrder(ret.Order), nil
+}
+
+// GetOrders gets every order for a product with the given status, or any status if it is empty
+func (c *Client) GetOrders(ctx context.Context, productID string, status string) ([]OrderResponse, error) {
+	ret := make([]OrderResponse, 0)
+	params := url.Values{"product_ids": {productID}}
+	if status != "" {
+		params.Set("order_status", status)
+	}
+	err := c.pages(ctx, "/orders/historical/batch", params, func(content []byte) (bool, string, error) {
+		var page OrdersResponse
+		err := json.Unmarshal(content, &page)
+		ret = append(ret, page.Orders...)
+		return page.HasNext, page.Cursor, err
+	})
+	return ret, err
+}
+
+// FindOrder looks an order up by the id we gave it. Coinbase can't search by it, so this pages through the
+// product's orders.
+func (c *Client) FindOrder(ctx context.Context, symbol string, clientOrderID string) (exchange.Order, error) {
+	productID, err := NativeSymbol(symbol)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	orders, err := c.GetOrders(ctx, productID, "")
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	for _, order := range orders {
+		if order.ClientOrderID == clientOrderID {
+			return toOrder(order), nil
+		}
+	}
+	return exchange.Order{}, fmt.Errorf("%w: no %s order with client id %s", exchange.ErrNotFound, productID, clientOrderID)
+}
+
+// CancelOrder stops an order from filling any more
+func (c *Client) CancelOrder(ctx context.Context, symbol string, id string) (exchange.Order, error) {
+	var ret CancelOrdersResponse
+	err := c.call(ctx, http.MethodPost, "/orders/batch_cancel", nil, CancelOrdersRequest{OrderIDs: []string{id}}, &ret)
+	if err != nil {
+		return exchange.Order{}, err
+	}
+	for _, result := range ret.Results {
+		// Canceling a closed order fails, but it has stopped filling all the same
+		if result.OrderID == id && !result.Success && result.FailureReason != "DUPLICATE_CANCEL_REQUEST" && result.FailureReason != "ORDER_ALREADY_DONE" {
+			return exchange.Order{}, &APIError{StatusCode: http.StatusOK, Code: cancelFailure(result.FailureReason), Message: result.FailureReason}
+		}
+	}
+	return c.GetOrder(ctx, symbol, id)
+}
+
+// OpenOrders is every order on a market that can still fill
+func (c *Client) OpenOrders(ctx context.Context, symbol string) ([]exchange.Order, error) {
+	ret := make([]exchange.Order, 0)
+	productID, err := NativeSymbol(symbol)
+	if err != nil {
+		return ret, err
+	}
+	orders, err := c.GetOrders(ctx, productID, "OPEN")
+	if err != nil {
+		return ret, err
+	}
+	for _, order := range orders {
+		ret = append(ret, toOrder(order))
+	}
+	return ret, nil
+}
+
+// createOrderRequest is an order in coinbase's words
+func createOrderRequest(order exchange.OrderRequest) (CreateOrderRequest, error) {
+	productID, err := NativeSymbol(order.Symbol)
+	if err != nil {
+		return CreateOrderRequest{}, err
+	}
+	request := CreateOrderRequest{ClientOrderID: order.ClientOrderID, ProductID: productID, Side: order.Side}
+	limit := &LimitConfiguration{BaseSize: order.Quantity.String(), LimitPrice: order.Limit.String()}
+	switch {
+	case order.Type == exchange.Market && (order.TimeInForce == exchange.ImmediateOrCancel || order.TimeInForce == ""):
+		request.OrderConfiguration.MarketMarketIOC = &MarketConfiguration{BaseSize: order.Quantity.String()}
+	case order.Type == exchange.Limit && order.TimeInForce == exchange.GoodTilCancelled:
+		request.OrderConfiguration.LimitLimitGTC = limit
+	case order.Type == exchange.Limit && order.TimeInForce == exchange.PostOnly:
+		limit.PostOnly = true
+		request.OrderConfiguration.LimitLimitGTC = limit
+	case order.Type == exchange.Limit && order.TimeInForce == exchange.ImmediateOrCancel:
+		request.OrderConfiguration.SorLimitIOC = limit
+	case order.Type == exchange.Limit && order.TimeInForce == exchange.FillOrKill:
+		request.OrderConfiguration.LimitLimitFOK = limit
+	default:
+		return CreateOrderRequest{}, fmt.Errorf("%w: coinbase has no %s %s orders", exchange.ErrUnsupported, order.TimeInForce, order.Type)
+	}
+	return request, nil
+}
+
+// createOrderError is the error for an order coinbase turned down, named by the most specific reason it gave
+func createOrderError(created CreateOrderResponse, resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: created.ErrorResponse.Message}
+	if resp.Request != nil {
+		apiErr.URL = resp.Request.URL.String()
+	}
+	for _, reason := range []string{
+		created.ErrorResponse.PreviewFailureReason,
+		created.ErrorResponse.NewOrderFailureReason,
+		created.ErrorResponse.Error,
+		created.FailureReason,
+	} {
+		if reason != "" && !strings.HasPrefix(reason, "UNKNOWN") {
+			apiErr.Code = reason
+			break
+		}
+	}
+	return apiErr
+}
+
+func cancelFailure(reason string) string {
+	if reason == "UNKNOWN_CANCEL_ORDER" {
+		return "NOT_FOUND"
+	}
+	return reason
+}
+
+func toMarketInfo(product ProductResponse) exchange.MarketInfo {
+	tickSize := product.PriceIncrement
+	if tickSize == "" {
+		tickSize = product.QuoteIncrement
+	}
+	return exchange.MarketInfo{
+		Symbol:      product.ProductID,
+		Native:      product.ProductID,
+		Base:        product.BaseCurrencyID,
+		Quote:       product.QuoteCurrencyID,
+		MinQuantity: toDecimal(product.BaseMinSize),
+		StepSize:    toDecimal(product.BaseIncrement),
+		TickSize:    toDecimal(tickSize),
+		Active:      product.Status == "online" && !product.TradingDisabled && !product.IsDisabled && !product.CancelOnly,
+	}
+}
+
+func toOrder(order OrderResponse) exchange.Order {
+	ret := exchange.Order{
+		ID:             order.OrderID,
+		ClientOrderID:  order.ClientOrderID,
+		Symbol:         order.ProductID,
+		Side:           order.Side,
+		Type:           order.OrderType,
+		FilledQuantity: toDecimal(order.FilledSize),
+		Proceeds:       toDecimal(order.FilledValue),
+		Commission:     toDecimal(order.TotalFees),
+		Status:         exchange.Closed,
+		CreatedAt:      order.CreatedTime,
+		UpdatedAt:      order.CreatedTime,
+	}
+	for canonical, native := range timesInForce {
+		if order.TimeInForce == native {
+			ret.TimeInForce = canonical
+		}
+	}
+	config := order.OrderConfiguration
+	switch {
+	case config.MarketMarketIOC != nil:
+		ret.Quantity = toDecimal(config.MarketMarketIOC.BaseSize)
+		ret.TimeInForce = exchange.ImmediateOrCancel
+	case config.LimitLimitGTC != nil:
+		ret.Quantity = toDecimal(config.LimitLimitGTC.BaseSize)
+		ret.Limit = toDecimal(config.LimitLimitGTC.LimitPrice)
+		if config.LimitLimitGTC.PostOnly {
+			ret.TimeInForce = exchange.PostOnly
+		}
+	case config.SorLimitIOC != nil:
+		ret.Quantity = toDecimal(config.SorLimitIOC.BaseSize)
+		ret.Limit = toDecimal(config.SorLimitIOC.LimitPrice)
+	case config.LimitLimitFOK != nil:
+		ret.Quantity = toDecimal(config.LimitLimitFOK.BaseSize)
+		ret.Limit = toDecimal(config.LimitLimitFOK.LimitPrice)
+	}
+	if order.LastFillTime.After(ret.UpdatedAt) {
+		ret.UpdatedAt = order.LastFillTime
+	}
+	if openStatuses[order.Status] {
+		ret.Status = exchange.Open
+	} else {
+		ret.ClosedAt = ret.UpdatedAt
+	}
+	return ret
+}
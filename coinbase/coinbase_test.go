@@ -0,0 +1,386 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+var testNow = time.Date(2023, 11, 14, 22, 13, 30, 0, time.UTC)
+
+// stubServer answers like coinbase, a page of two at a time, and fails any request whose HMAC signature is off
+type stubServer struct {
+	t      *testing.T
+	mu     sync.Mutex
+	orders []OrderResponse
+	calls  map[string]int
+}
+
+func newTestClient(t *testing.T) (*Client, *stubServer) {
+	stub := &stubServer{t: t, calls: map[string]int{}}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	client := NewClient(WithBaseURL(server.URL), WithSigner(NewHMACSigner("key", "secret")), WithClock(func() time.Time { return testNow }), WithRateLimiter(nil))
+	return client, stub
+}
+
+func writeStubError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "message": strings.ToLower(code)})
+}
+
+// page serves items two at a time, with the cursor being where the next page starts
+func page(r *http.Request, total int) (int, int, string) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+	end := start + 2
+	if end >= total {
+		return start, total, ""
+	}
+	return start, end, strconv.Itoa(end)
+}
+
+func (s *stubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	timestamp := r.Header.Get("CB-ACCESS-TIMESTAMP")
+	if r.Header.Get("CB-ACCESS-KEY") != "key" || r.Header.Get("CB-ACCESS-SIGN") != makeHMACSignature("secret", timestamp, r.Method, r.URL.Path, body) {
+		writeStubError(w, http.StatusUnauthorized, "UNAUTHENTICATED")
+		return
+	}
+	if timestamp != strconv.FormatInt(testNow.Unix(), 10) {
+		writeStubError(w, http.StatusUnauthorized, "UNAUTHENTICATED")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, brokeragePath)
+	s.calls[path]++
+	switch {
+	case path == "/time":
+		fmt.Fprint(w, `{"iso":"2023-11-14T22:13:30Z","epochSeconds":"1700000010"}`)
+	case path == "/key_permissions":
+		fmt.Fprint(w, `{"can_view":true,"can_trade":true,"can_transfer":false,"portfolio_uuid":"8c8a3e62-2c33-4dd1-9f6a-5e0b4d2c1a90","portfolio_type":"DEFAULT"}`)
+	case path == "/accounts":
+		accounts := []string{"BTC", "DOGE", "ETH", "USD", "USDC"}
+		start, end, cursor := page(r, len(accounts))
+		response := AccountsResponse{HasNext: cursor != "", Cursor: cursor}
+		for i, currency := range accounts[start:end] {
+			response.Accounts = append(response.Accounts, AccountResponse{
+				UUID:             strconv.Itoa(start + i),
+				Currency:         currency,
+				AvailableBalance: MoneyResponse{Value: "10", Currency: currency},
+				Hold:             MoneyResponse{Value: "2.5", Currency: currency},
+			})
+		}
+		json.NewEncoder(w).Encode(response)
+	case path == "/products/DOGE-USD":
+		fmt.Fprint(w, `{"product_id":"DOGE-USD","price":"0.0734","base_increment":"0.1","quote_increment":"0.00001","price_increment":"0.00001",
+			"base_min_size":"1","base_currency_id":"DOGE","quote_currency_id":"USD","status":"online","trading_disabled":false,"is_disabled":false,"cancel_only":false}`)
+	case strings.HasPrefix(path, "/products/") && !strings.Contains(path, "DOGE-USD"):
+		writeStubError(w, http.StatusNotFound, "NOT_FOUND")
+	case path == "/products/DOGE-USD/ticker":
+		fmt.Fprint(w, `{"trades":[{"trade_id":"1","product_id":"DOGE-USD","price":"0.07341","size":"100","time":"2023-11-14T22:13:29Z","side":"BUY"}],"best_bid":"0.0734","best_ask":"0.07342"}`)
+	case path == "/products/DOGE-USD/candles":
+		if r.URL.Query().Get("granularity") != "ONE_MINUTE" {
+			writeStubError(w, http.StatusBadRequest, "INVALID_ARGUMENT")
+			return
+		}
+		// Newest first, and the newest is still trading
+		fmt.Fprint(w, `{"candles":[{"start":"1700000000","low":"0.0733","high":"0.0735","open":"0.0734","close":"0.07341","volume":"500"},
+			{"start":"1699999940","low":"0.0732","high":"0.0735","open":"0.0733","close":"0.0734","volume":"12000"},
+			{"start":"1699999880","low":"0.0731","high":"0.0734","open":"0.0732","close":"0.0733","volume":"9000"}]}`)
+	case path == "/orders" && r.Method == http.MethodPost:
+		s.createOrder(w, body)
+	case path == "/orders/historical/batch":
+		matching := make([]OrderResponse, 0)
+		for _, order := range s.orders {
+			status := r.URL.Query().Get("order_status")
+			if order.ProductID == r.URL.Query().Get("product_ids") && (status == "" || order.Status == status) {
+				matching = append(matching, order)
+			}
+		}
+		start, end, cursor := page(r, len(matching))
+		json.NewEncoder(w).Encode(OrdersResponse{Orders: matching[start:end], HasNext: cursor != "", Cursor: cursor})
+	case strings.HasPrefix(path, "/orders/historical/"):
+		for _, order := range s.orders {
+			if order.OrderID == strings.TrimPrefix(path, "/orders/historical/") {
+				json.NewEncoder(w).Encode(GetOrderResponse{Order: order})
+				return
+			}
+		}
+		writeStubError(w, http.StatusNotFound, "NOT_FOUND")
+	case path == "/orders/batch_cancel":
+		var request CancelOrdersRequest
+		json.Unmarshal(body, &request)
+		var response CancelOrdersResponse
+		for _, id := range request.OrderIDs {
+			result := struct {
+				Success       bool   `json:"success"`
+				FailureReason string `json:"failure_reason"`
+				OrderID       string `json:"order_id"`
+			}{FailureReason: "UNKNOWN_CANCEL_ORDER", OrderID: id}
+			for i, order := range s.orders {
+				if order.OrderID == id && order.Status == "OPEN" {
+					s.orders[i].Status = "CANCELLED"
+					result.Success, result.FailureReason = true, "UNKNOWN_CANCEL_FAILURE_REASON"
+				}
+			}
+			response.Results = append(response.Results, result)
+		}
+		json.NewEncoder(w).Encode(response)
+	default:
+		writeStubError(w, http.StatusNotFound, "NOT_FOUND")
+	}
+}
+
+func (s *stubServer) createOrder(w http.ResponseWriter, body []byte) {
+	var request CreateOrderRequest
+	err := json.Unmarshal(body, &request)
+	if err != nil || request.ClientOrderID == "" {
+		writeStubError(w, http.StatusBadRequest, "INVALID_ARGUMENT")
+		return
+	}
+	order := OrderResponse{
+		OrderID:            fmt.Sprintf("0000-%d", len(s.orders)),
+		ProductID:          request.ProductID,
+		OrderConfiguration: request.OrderConfiguration,
+		Side:               request.Side,
+		ClientOrderID:      request.ClientOrderID,
+		Status:             "OPEN",
+		CreatedTime:        testNow,
+		FilledSize:         "0",
+		FilledValue:        "0",
+		TotalFees:          "0",
+		OrderType:          "LIMIT",
+	}
+	config := request.OrderConfiguration
+	switch {
+	case config.LimitLimitGTC != nil:
+		order.TimeInForce = "GOOD_UNTIL_CANCELLED"
+		if toDecimal(config.LimitLimitGTC.BaseSize).GreaterThan(decimal.NewFromInt(1000)) {
+			fmt.Fprint(w, `{"success":false,"failure_reason":"UNKNOWN_FAILURE_REASON","error_response":{"error":"INSUFFICIENT_FUND","message":"Insufficient balance in source account","preview_failure_reason":"PREVIEW_INSUFFICIENT_FUND"}}`)
+			return
+		}
+	case config.SorLimitIOC != nil:
+		// Takes right away at the ask
+		order.TimeInForce = "IMMEDIATE_OR_CANCEL"
+		order.Status = "FILLED"
+		size := toDecimal(config.SorLimitIOC.BaseSize)
+		order.FilledSize = size.String()
+		order.FilledValue = size.Mul(decimal.RequireFromString("0.07342")).String()
+		order.TotalFees = "0.01"
+		order.LastFillTime = testNow.Add(time.Second)
+	default:
+		writeStubError(w, http.StatusBadRequest, "UNSUPPORTED_ORDER_CONFIGURATION")
+		return
+	}
+	s.orders = append(s.orders, order)
+	fmt.Fprintf(w, `{"success":true,"order_id":%q,"success_response":{"order_id":%q,"product_id":%q,"side":%q,"client_order_id":%q}}`,
+		order.OrderID, order.OrderID, order.ProductID, order.Side, order.ClientOrderID)
+}
+
+func TestMarketDataFromStub(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	err := client.Ping(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	market, err := client.Market(ctx, "doge-usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if market.Symbol != "DOGE-USD" || market.Base != "DOGE" || market.Quote != "USD" || !market.Active {
+		t.Errorf("Market was %+v", market)
+	}
+	if market.TickSize.String() != "0.00001" || market.StepSize.String() != "0.1" || market.MinQuantity.String() != "1" {
+		t.Errorf("Market rules were tick %s, step %s, min %s", market.TickSize, market.StepSize, market.MinQuantity)
+	}
+	_, err = client.Market(ctx, "SHIB-USD")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected an unknown product to be not found, got %v", err)
+	}
+
+	ticker, err := client.Ticker(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Last.String() != "0.07341" || ticker.Bid.String() != "0.0734" || ticker.Ask.String() != "0.07342" {
+		t.Errorf("Ticker was %+v", ticker)
+	}
+
+	candles, err := client.Candles(ctx, "DOGE-USD", exchange.Interval1Min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 {
+		t.Fatalf("Got %d candles, expected the two closed ones", len(candles))
+	}
+	if !candles[0].StartsAt.Equal(time.Unix(1699999880, 0)) || candles[1].Close.String() != "0.0734" || candles[1].Volume.String() != "12000" {
+		t.Errorf("Candles were %+v", candles)
+	}
+	_, err = client.Candles(ctx, "DOGE-USD", "3min")
+	if !errors.Is(err, exchange.ErrUnsupported) {
+		t.Errorf("Expected an unknown interval to be unsupported, got %v", err)
+	}
+}
+
+func TestAccountsArePaged(t *testing.T) {
+	client, stub := newTestClient(t)
+	ctx := context.Background()
+
+	account, err := client.Account(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.ID != "8c8a3e62-2c33-4dd1-9f6a-5e0b4d2c1a90" {
+		t.Errorf("Account was %s", account.ID)
+	}
+
+	balances, err := client.Balances(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 5 || stub.calls["/accounts"] != 3 {
+		t.Fatalf("Got %d balances in %d pages, expected 5 in 3", len(balances), stub.calls["/accounts"])
+	}
+	if balances[4].Currency != "USDC" || balances[4].Total.String() != "12.5" || balances[4].Available.String() != "10" {
+		t.Errorf("Last balance was %+v", balances[4])
+	}
+
+	wrongSecret := NewClient(WithBaseURL(client.BaseURL()), WithSigner(NewHMACSigner("key", "not the secret")), WithClock(func() time.Time { return testNow }), WithRateLimiter(nil))
+	_, err = wrongSecret.Account(ctx)
+	if !errors.Is(err, exchange.ErrBadCredentials) {
+		t.Errorf("Expected a bad signature to be bad credentials, got %v", err)
+	}
+}
+
+func TestOrdersOnStub(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	resting := make([]exchange.Order, 0)
+	for i := 0; i < 3; i++ {
+		order, err := client.PlaceOrder(ctx, exchange.OrderRequest{
+			Symbol:      "DOGE-USD",
+			Side:        exchange.Buy,
+			Type:        exchange.Limit,
+			Quantity:    decimal.NewFromInt(100),
+			Limit:       decimal.RequireFromString("0.07"),
+			TimeInForce: exchange.GoodTilCancelled,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resting = append(resting, order)
+	}
+	if resting[0].Status != exchange.Open || resting[0].Limit.String() != "0.07" || resting[0].TimeInForce != exchange.GoodTilCancelled || resting[0].ClientOrderID == "" {
+		t.Errorf("Resting order was %+v", resting[0])
+	}
+
+	taken, err := client.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:        "DOGE-USD",
+		Side:          exchange.Buy,
+		Type:          exchange.Limit,
+		Quantity:      decimal.NewFromInt(50),
+		Limit:         decimal.RequireFromString("0.08"),
+		TimeInForce:   exchange.ImmediateOrCancel,
+		ClientOrderID: "d3c0f1a2-8e2b-4a57-b9b8-0c5e1f7d2a33",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken.Status != exchange.Closed || taken.FilledQuantity.String() != "50" || taken.Proceeds.String() != "3.671" || taken.Commission.String() != "0.01" {
+		t.Errorf("Taken order was %+v", taken)
+	}
+	if !taken.ClosedAt.Equal(testNow.Add(time.Second)) {
+		t.Errorf("Taken order closed at %s", taken.ClosedAt)
+	}
+
+	// The taken order is on the second page of the product's orders
+	found, err := client.FindOrder(ctx, "DOGE-USD", "d3c0f1a2-8e2b-4a57-b9b8-0c5e1f7d2a33")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != taken.ID {
+		t.Errorf("Found order %s, expected %s", found.ID, taken.ID)
+	}
+	_, err = client.FindOrder(ctx, "DOGE-USD", "never-sent")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected an order coinbase never saw to be not found, got %v", err)
+	}
+
+	open, err := client.OpenOrders(ctx, "DOGE-USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 3 {
+		t.Errorf("Got %d open orders, expected 3", len(open))
+	}
+
+	canceled, err := client.CancelOrder(ctx, "DOGE-USD", resting[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Status != exchange.Closed {
+		t.Errorf("Canceled order was %s", canceled.Status)
+	}
+	_, err = client.CancelOrder(ctx, "DOGE-USD", "no-such-order")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected canceling an unknown order to be not found, got %v", err)
+	}
+
+	_, err = client.PlaceOrder(ctx, exchange.OrderRequest{
+		Symbol:      "DOGE-USD",
+		Side:        exchange.Buy,
+		Type:        exchange.Limit,
+		Quantity:    decimal.NewFromInt(5000),
+		Limit:       decimal.RequireFromString("0.07"),
+		TimeInForce: exchange.GoodTilCancelled,
+	})
+	if !errors.Is(err, exchange.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds, got %v", err)
+	}
+}
+
+func TestOrderConfigurations(t *testing.T) {
+	postOnly, err := createOrderRequest(exchange.OrderRequest{Symbol: "DOGE-USD", Side: exchange.Sell, Type: exchange.Limit, Quantity: decimal.NewFromInt(10), Limit: decimal.RequireFromString("0.08"), TimeInForce: exchange.PostOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if postOnly.OrderConfiguration.LimitLimitGTC == nil || !postOnly.OrderConfiguration.LimitLimitGTC.PostOnly {
+		t.Errorf("Post only order was sent as %+v", postOnly.OrderConfiguration)
+	}
+
+	market, err := createOrderRequest(exchange.OrderRequest{Symbol: "DOGE-USD", Side: exchange.Buy, Type: exchange.Market, Quantity: decimal.NewFromInt(10), TimeInForce: exchange.ImmediateOrCancel})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if market.OrderConfiguration.MarketMarketIOC == nil || market.OrderConfiguration.MarketMarketIOC.BaseSize != "10" {
+		t.Errorf("Market order was sent as %+v", market.OrderConfiguration)
+	}
+
+	_, err = createOrderRequest(exchange.OrderRequest{Symbol: "DOGE-USD", Side: exchange.Buy, Type: exchange.Market, Quantity: decimal.NewFromInt(10), TimeInForce: exchange.GoodTilCancelled})
+	if !errors.Is(err, exchange.ErrUnsupported) {
+		t.Errorf("Expected a resting market order to be unsupported, got %v", err)
+	}
+}
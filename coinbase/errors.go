@@ -0,0 +1,89 @@
+package coinbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"cryptofu/exchange"
+)
+
+var (
+	// codeToErr maps coinbase's error names, and the reasons it gives for turning an order down
+	codeToErr = map[string]error{
+		"NOT_FOUND":                           exchange.ErrNotFound,
+		"UNAUTHENTICATED":                     exchange.ErrBadCredentials,
+		"PERMISSION_DENIED":                   exchange.ErrBadCredentials,
+		"RESOURCE_EXHAUSTED":                  exchange.ErrThrottled,
+		"INSUFFICIENT_FUND":                   exchange.ErrInsufficientFunds,
+		"PREVIEW_INSUFFICIENT_FUND":           exchange.ErrInsufficientFunds,
+		"PREVIEW_INVALID_BASE_SIZE_TOO_SMALL": exchange.ErrMinTradeRequirementNotMet,
+		"INVALID_LIMIT_PRICE_POST_ONLY":       exchange.ErrInvalidOrder,
+		"INVALID_SIZE_PRECISION":              exchange.ErrInvalidOrder,
+		"INVALID_PRICE_PRECISION":             exchange.ErrInvalidOrder,
+		"UNSUPPORTED_ORDER_CONFIGURATION":     exchange.ErrInvalidOrder,
+		"ORDER_ENTRY_DISABLED":                exchange.ErrMarketOffline,
+		"INVALID_PRODUCT_ID":                  exchange.ErrNotFound,
+	}
+	statusToErr = map[int]error{
+		http.StatusTooManyRequests: exchange.ErrThrottled,
+		http.StatusUnauthorized:    exchange.ErrBadCredentials,
+		http.StatusNotFound:        exchange.ErrNotFound,
+	}
+)
+
+// APIError is a non-success response from coinbase, or an order it turned down. Match it with the exchange
+// package's errors, e.g. errors.Is(err, exchange.ErrInsufficientFunds).
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	URL        string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("Status Code: %d from %s", e.StatusCode, e.URL)
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("Status Code: %d %s from %s", e.StatusCode, e.Code, e.URL)
+	}
+	return fmt.Sprintf("Status Code: %d %s (%s) from %s", e.StatusCode, e.Code, e.Message, e.URL)
+}
+
+// Is lets errors.Is match the exchange package's errors for the response code and status
+func (e *APIError) Is(target error) bool {
+	if codeErr, ok := codeToErr[e.Code]; ok && codeErr == target {
+		return true
+	}
+	if statusErr, ok := statusToErr[e.StatusCode]; ok && statusErr == target {
+		return true
+	}
+	return false
+}
+
+// newAPIError reads a failed response into an *APIError. It closes the response body.
+func newAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.URL = resp.Request.URL.String()
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+	var body struct {
+		Error   string
+		Message string
+	}
+	// Not every failure comes with a json body, the status code is still worth returning
+	if json.Unmarshal(content, &body) == nil {
+		apiErr.Code = body.Error
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
@@ -0,0 +1,199 @@
+package coinbase
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Coinbase sends numbers as strings and leaves them empty when there is nothing to say, so amounts are kept
+// as strings here and read with toDecimal.
+
+// ProductsResponse lists coinbase's products
+type ProductsResponse struct {
+	Products    []ProductResponse `json:"products"`
+	NumProducts int               `json:"num_products"`
+}
+
+// ProductResponse is one product and its trading rules
+type ProductResponse struct {
+	ProductID       string `json:"product_id"`
+	Price           string `json:"price"`
+	BaseIncrement   string `json:"base_increment"`
+	QuoteIncrement  string `json:"quote_increment"`
+	PriceIncrement  string `json:"price_increment"`
+	BaseMinSize     string `json:"base_min_size"`
+	BaseCurrencyID  string `json:"base_currency_id"`
+	QuoteCurrencyID string `json:"quote_currency_id"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+	IsDisabled      bool   `json:"is_disabled"`
+	CancelOnly      bool   `json:"cancel_only"`
+}
+
+// CandlesResponse is a product's candles, newest first
+type CandlesResponse struct {
+	Candles []CandleResponse `json:"candles"`
+}
+
+// CandleResponse is a candle. Start is in unix seconds.
+type CandleResponse struct {
+	Start  string `json:"start"`
+	Low    string `json:"low"`
+	High   string `json:"high"`
+	Open   string `json:"open"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+}
+
+// MarketTradesResponse is a product's latest trades and best prices
+type MarketTradesResponse struct {
+	Trades  []TradeResponse `json:"trades"`
+	BestBid string          `json:"best_bid"`
+	BestAsk string          `json:"best_ask"`
+}
+
+// TradeResponse is one trade on a product
+type TradeResponse struct {
+	TradeID   string    `json:"trade_id"`
+	ProductID string    `json:"product_id"`
+	Price     string    `json:"price"`
+	Size      string    `json:"size"`
+	Time      time.Time `json:"time"`
+	Side      string    `json:"side"`
+}
+
+// KeyPermissionsResponse is what the signing key may do, and the portfolio it belongs to
+type KeyPermissionsResponse struct {
+	CanView       bool   `json:"can_view"`
+	CanTrade      bool   `json:"can_trade"`
+	CanTransfer   bool   `json:"can_transfer"`
+	PortfolioUUID string `json:"portfolio_uuid"`
+	PortfolioType string `json:"portfolio_type"`
+}
+
+// AccountsResponse is a page of accounts, one for each currency
+type AccountsResponse struct {
+	Accounts []AccountResponse `json:"accounts"`
+	HasNext  bool              `json:"has_next"`
+	Cursor   string            `json:"cursor"`
+	Size     int               `json:"size"`
+}
+
+// AccountResponse is one currency's balance. Hold is set aside for open orders.
+type AccountResponse struct {
+	UUID             string        `json:"uuid"`
+	Name             string        `json:"name"`
+	Currency         string        `json:"currency"`
+	AvailableBalance MoneyResponse `json:"available_balance"`
+	Hold             MoneyResponse `json:"hold"`
+	Active           bool          `json:"active"`
+}
+
+// MoneyResponse is an amount of a currency
+type MoneyResponse struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+// CreateOrderRequest is a new order. Exactly one of its configurations is set.
+type CreateOrderRequest struct {
+	ClientOrderID      string             `json:"client_order_id"`
+	ProductID          string             `json:"product_id"`
+	Side               string             `json:"side"`
+	OrderConfiguration OrderConfiguration `json:"order_configuration"`
+}
+
+// OrderConfiguration is the type and time in force of an order, which coinbase rolls into one
+type OrderConfiguration struct {
+	MarketMarketIOC *MarketConfiguration `json:"market_market_ioc,omitempty"`
+	SorLimitIOC     *LimitConfiguration  `json:"sor_limit_ioc,omitempty"`
+	LimitLimitGTC   *LimitConfiguration  `json:"limit_limit_gtc,omitempty"`
+	LimitLimitFOK   *LimitConfiguration  `json:"limit_limit_fok,omitempty"`
+}
+
+// MarketConfiguration is a market order's size
+type MarketConfiguration struct {
+	BaseSize  string `json:"base_size,omitempty"`
+	QuoteSize string `json:"quote_size,omitempty"`
+}
+
+// LimitConfiguration is a limit order's size and price
+type LimitConfiguration struct {
+	BaseSize   string `json:"base_size"`
+	LimitPrice string `json:"limit_price"`
+	PostOnly   bool   `json:"post_only,omitempty"`
+}
+
+// CreateOrderResponse says whether an order was taken. Turned down orders still come back as a 200.
+type CreateOrderResponse struct {
+	Success         bool   `json:"success"`
+	FailureReason   string `json:"failure_reason"`
+	OrderID         string `json:"order_id"`
+	SuccessResponse struct {
+		OrderID       string `json:"order_id"`
+		ProductID     string `json:"product_id"`
+		Side          string `json:"side"`
+		ClientOrderID string `json:"client_order_id"`
+	} `json:"success_response"`
+	ErrorResponse struct {
+		Error                 string `json:"error"`
+		Message               string `json:"message"`
+		ErrorDetails          string `json:"error_details"`
+		PreviewFailureReason  string `json:"preview_failure_reason"`
+		NewOrderFailureReason string `json:"new_order_failure_reason"`
+	} `json:"error_response"`
+}
+
+// GetOrderResponse wraps one order
+type GetOrderResponse struct {
+	Order OrderResponse `json:"order"`
+}
+
+// OrdersResponse is a page of orders
+type OrdersResponse struct {
+	Orders  []OrderResponse `json:"orders"`
+	HasNext bool            `json:"has_next"`
+	Cursor  string          `json:"cursor"`
+}
+
+// OrderResponse is an order coinbase has. FilledValue is the quote currency value of what filled.
+type OrderResponse struct {
+	OrderID            string             `json:"order_id"`
+	ProductID          string             `json:"product_id"`
+	OrderConfiguration OrderConfiguration `json:"order_configuration"`
+	Side               string             `json:"side"`
+	ClientOrderID      string             `json:"client_order_id"`
+	Status             string             `json:"status"`
+	TimeInForce        string             `json:"time_in_force"`
+	CreatedTime        time.Time          `json:"created_time"`
+	FilledSize         string             `json:"filled_size"`
+	AverageFilledPrice string             `json:"average_filled_price"`
+	FilledValue        string             `json:"filled_value"`
+	TotalFees          string             `json:"total_fees"`
+	OrderType          string             `json:"order_type"`
+	LastFillTime       time.Time          `json:"last_fill_time"`
+}
+
+// CancelOrdersRequest cancels orders by id
+type CancelOrdersRequest struct {
+	OrderIDs []string `json:"order_ids"`
+}
+
+// CancelOrdersResponse says which cancels worked
+type CancelOrdersResponse struct {
+	Results []struct {
+		Success       bool   `json:"success"`
+		FailureReason string `json:"failure_reason"`
+		OrderID       string `json:"order_id"`
+	} `json:"results"`
+}
+
+// toDecimal reads one of coinbase's string amounts, empty ones are zero
+func toDecimal(s string) decimal.Decimal {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"cryptofu/bot"
+	"cryptofu/exchange"
+	"cryptofu/symbols"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// strategies are the strategies a bot can be configured with, by name
+var strategies = map[string]func(config botConfig) bot.Strategy{
+	"macd-tema": func(config botConfig) bot.Strategy {
+		return config.MACDTEMA
+	},
+}
+
+// config is the bots to run side by side
+type config struct {
+	Bots []botConfig `json:"bots"`
+}
+
+// botConfig is how a bot is set up and tuned. Anything left out keeps the defaults.
+type botConfig struct {
+	// Name tells bots apart, and is the venue's symbol for the pair unless set
+	Name     string        `json:"name"`
+	Pair     string        `json:"pair"`
+	Interval string        `json:"interval"`
+	Mode     string        `json:"mode"`
+	Strategy string        `json:"strategy"`
+	Periods  bot.Periods   `json:"periods"`
+	MACDTEMA *bot.MACDTEMA `json:"macdTema"`
+}
+
+// defaultBotConfig is a paper trading bot on pair, with the strategy the bot has always traded with
+func defaultBotConfig(pair string) botConfig {
+	return botConfig{
+		Pair:     pair,
+		Interval: exchange.Interval1Min,
+		Mode:     bot.Modes["Paper"],
+		Strategy: "macd-tema",
+		MACDTEMA: bot.NewMACDTEMA(),
+	}
+}
+
+// loadConfig reads a config file. Without a file to read there is one default bot on pair, and bots in the file
+// that leave out their pair trade pair too.
+func loadConfig(name string, pair string) (config, error) {
+	if name == "" {
+		return config{Bots: []botConfig{defaultBotConfig(pair)}}, nil
+	}
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		return config{}, err
+	}
+	var file struct {
+		Bots []json.RawMessage `json:"bots"`
+	}
+	err = json.Unmarshal(content, &file)
+	if err != nil {
+		return config{}, fmt.Errorf("reading config %s: %w", name, err)
+	}
+	if len(file.Bots) == 0 {
+		return config{}, fmt.Errorf("config %s has no bots", name)
+	}
+	loaded := config{Bots: make([]botConfig, 0, len(file.Bots))}
+	for i, raw := range file.Bots {
+		// Every bot starts from the defaults so it only has to say what's different
+		bot := defaultBotConfig(pair)
+		err = json.Unmarshal(raw, &bot)
+		if err != nil {
+			return config{}, fmt.Errorf("reading bot %d of config %s: %w", i+1, name, err)
+		}
+		err = bot.validate()
+		if err != nil {
+			return config{}, fmt.Errorf("bot %d of config %s: %w", i+1, name, err)
+		}
+		loaded.Bots = append(loaded.Bots, bot)
+	}
+	return loaded, nil
+}
+
+// needsVenue says whether any bot trades on the venue rather than backtesting against the mock exchange
+func (c config) needsVenue() bool {
+	for _, settings := range c.Bots {
+		if !settings.backtest() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c botConfig) backtest() bool {
+	return c.Mode == bot.Modes["Testing"]
+}
+
+// symbol is what the bot trades its pair as. Backtests trade on the mock exchange, which writes symbols
+// BASE-QUOTE the way bittrex does, so they don't need the venue's registry.
+func (c botConfig) symbol(registry *symbols.Registry) (string, error) {
+	if c.backtest() {
+		base, quote, err := symbols.ParsePair(c.Pair)
+		if err != nil {
+			return "", err
+		}
+		return base + "-" + quote, nil
+	}
+	return registry.Symbol(c.Pair)
+}
+
+// validate catches what can be caught before the bot is set up
+func (c botConfig) validate() error {
+	if _, ok := strategies[c.Strategy]; !ok {
+		return fmt.Errorf("unknown strategy %q", c.Strategy)
+	}
+	if c.Strategy == "macd-tema" && c.MACDTEMA == nil {
+		return fmt.Errorf("the %s strategy needs its macdTema settings", c.Strategy)
+	}
+	for _, mode := range bot.Modes {
+		if c.Mode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q", c.Mode)
+}
+
+// strategy is the strategy the bot is configured with
+func (c botConfig) strategy() bot.Strategy {
+	return strategies[c.Strategy](c)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cryptofu/bot"
+	"cryptofu/exchange"
+	"cryptofu/symbols"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "cryptofu-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "bots.json")
+	err = ioutil.WriteFile(name, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	config, err := loadConfig("", "DOGE/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Bots) != 1 {
+		t.Fatalf("Got %d bots, expected 1", len(config.Bots))
+	}
+	got := config.Bots[0]
+	if got.Pair != "DOGE/USD" || got.Mode != bot.Modes["Paper"] || got.Interval != exchange.Interval1Min || got.strategy().Name() != "macd-tema" {
+		t.Errorf("Got %+v, expected the default bot", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	name, cleanup := writeConfig(t, `{"bots": [
+		{"name": "doge-fast", "periods": {"macdFast": 8, "macdSlow": 21}, "macdTema": {"buyHistogram": "0.02%", "trailLag": "1.5atr"}},
+		{"pair": "BTC/USD", "interval": "5min", "mode": "testing"}
+	]}`)
+	defer cleanup()
+
+	config, err := loadConfig(name, "DOGE/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Bots) != 2 {
+		t.Fatalf("Got %d bots, expected 2", len(config.Bots))
+	}
+	doge := config.Bots[0]
+	if doge.Name != "doge-fast" || doge.Pair != "DOGE/USD" || doge.Mode != bot.Modes["Paper"] {
+		t.Errorf("Got %+v", doge)
+	}
+	if doge.Periods.MACDFast != 8 || doge.Periods.MACDSlow != 21 || doge.Periods.Signal != 0 {
+		t.Errorf("Got periods %+v", doge.Periods)
+	}
+	strategy := doge.MACDTEMA
+	if strategy.BuyHistogram.String() != "0.02%" || strategy.TrailLag.String() != "1.5atr" || strategy.SellGain.String() != "10" {
+		t.Errorf("Got %s, %s and %s", strategy.BuyHistogram, strategy.TrailLag, strategy.SellGain)
+	}
+
+	btc := config.Bots[1]
+	if btc.Pair != "BTC/USD" || btc.Interval != exchange.Interval5Min || btc.Mode != bot.Modes["Testing"] {
+		t.Errorf("Got %+v", btc)
+	}
+	// Bots don't share their strategies
+	if btc.MACDTEMA == doge.MACDTEMA || btc.MACDTEMA.BuyHistogram.String() != "6" {
+		t.Errorf("Got %s, expected its own default strategy", btc.MACDTEMA.BuyHistogram)
+	}
+}
+
+func TestLoadConfigRejectsUnknowns(t *testing.T) {
+	for _, content := range []string{
+		`{"bots": []}`,
+		`{"bots": [{"mode": "yolo"}]}`,
+		`{"bots": [{"strategy": "hodl"}]}`,
+		`{"bots": [{"macdTema": {"sellGain": "lots"}}]}`,
+		`{"bots": [{"macdTema": null}]}`,
+	} {
+		name, cleanup := writeConfig(t, content)
+		_, err := loadConfig(name, "DOGE/USD")
+		cleanup()
+		if err == nil {
+			t.Errorf("Loaded %s, expected an error", content)
+		}
+	}
+}
+
+func TestBotConfigSymbol(t *testing.T) {
+	backtest := defaultBotConfig("doge/usd")
+	backtest.Mode = bot.Modes["Testing"]
+	config := config{Bots: []botConfig{backtest}}
+	if config.needsVenue() {
+		t.Error("Expected backtests not to need the venue")
+	}
+	// Backtests resolve against the mock exchange without a registry
+	symbol, err := backtest.symbol(nil)
+	if err != nil || symbol != "DOGE-USD" {
+		t.Errorf("Got %s and %v, expected DOGE-USD", symbol, err)
+	}
+
+	paper := defaultBotConfig("DOGE/USD")
+	config.Bots = append(config.Bots, paper)
+	if !config.needsVenue() {
+		t.Error("Expected a paper bot to need the venue")
+	}
+	registry := symbols.New("binance", time.Now(), []exchange.MarketInfo{{Symbol: "DOGEUSDT", Base: "DOGE", Quote: "USD", Active: true}})
+	symbol, err = paper.symbol(registry)
+	if err != nil || symbol != "DOGEUSDT" {
+		t.Errorf("Got %s and %v, expected DOGEUSDT", symbol, err)
+	}
+}
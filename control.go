@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// controlHandler lets bots be looked after while the process runs. GET /bots lists them, and POSTing to
+// /bots/<name>/start, /stop or /restart does what it says to one of them. There is no auth, so only listen
+// somewhere private.
+func controlHandler(o *orchestrator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bots", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeStatuses(w, o)
+	})
+	mux.HandleFunc("/bots/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/bots/"), "/")
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		name, action := parts[0], parts[1]
+		var err error
+		switch action {
+		case "start":
+			err = o.start(name)
+		case "stop":
+			err = o.stop(name)
+		case "restart":
+			err = o.restart(name)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), controlStatus(err))
+			return
+		}
+		writeStatuses(w, o)
+	})
+	return mux
+}
+
+// controlStatus is the http status for an orchestrator error
+func controlStatus(err error) int {
+	switch {
+	case errors.Is(err, errUnknownBot):
+		return http.StatusNotFound
+	case errors.Is(err, errBotRunning), errors.Is(err, errBotNotRunning):
+		return http.StatusConflict
+	case errors.Is(err, errOrchestratorIdle):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeStatuses(w http.ResponseWriter, o *orchestrator) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(o.statuses())
+}
@@ -0,0 +1,49 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrThrottled means the venue is rate limiting us
+	ErrThrottled = errors.New("throttled")
+	// ErrInsufficientFunds means the account can't cover an order
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrMinTradeRequirementNotMet means an order was smaller than the market allows
+	ErrMinTradeRequirementNotMet = errors.New("minimum trade requirement not met")
+	// ErrBadCredentials matches any error caused by the key, secret or signature
+	ErrBadCredentials = errors.New("bad credentials")
+	// ErrNotFound means the thing asked for does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrMarketOffline means a market isn't taking orders
+	ErrMarketOffline = errors.New("market offline")
+	// ErrInvalidOrder means an order was turned down before it was sent
+	ErrInvalidOrder = errors.New("invalid order")
+	// ErrInvalidTimestamp means our clock and the venue's disagree by too much to trust a signed request
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	// ErrOrderUncertain means an order request was cut off after it may have reached the venue
+	ErrOrderUncertain = errors.New("order may or may not have been placed")
+	// ErrUnsupported means a venue has no way to do what was asked, like an interval it doesn't have
+	ErrUnsupported = errors.New("not supported")
+)
+
+// UncertainOrderError is returned when we can't tell if an order was placed. Match it with errors.Is(err, ErrOrderUncertain).
+type UncertainOrderError struct {
+	Order OrderRequest
+	Err   error
+}
+
+func (e *UncertainOrderError) Error() string {
+	return fmt.Sprintf("%s: %s %s: %s", ErrOrderUncertain, e.Order.Side, e.Order.Symbol, e.Err)
+}
+
+// Unwrap exposes the underlying network or context error
+func (e *UncertainOrderError) Unwrap() error {
+	return e.Err
+}
+
+// Is lets errors.Is match ErrOrderUncertain
+func (e *UncertainOrderError) Is(target error) bool {
+	return target == ErrOrderUncertain
+}
@@ -0,0 +1,151 @@
+// Package exchange is what the bot needs from a venue, in shapes that don't belong to any one of them.
+//
+// Symbols are written BASE-QUOTE, like DOGE-USD, and intervals are the keys of bittrex.CandleIntervals:
+// 1min, 5min, 1hour and 1day. Adapters translate both to whatever their venue calls them.
+package exchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// Interval1Min is a one minute candle
+	Interval1Min = "1min"
+	// Interval5Min is a five minute candle
+	Interval5Min = "5min"
+	// Interval1Hour is an hour candle
+	Interval1Hour = "1hour"
+	// Interval1Day is a day candle
+	Interval1Day = "1day"
+
+	// Buy is the side of an order spending the quote currency
+	Buy = "BUY"
+	// Sell is the side of an order spending the base currency
+	Sell = "SELL"
+
+	// Limit orders trade at their limit or better
+	Limit = "LIMIT"
+	// Market orders trade at whatever the book has
+	Market = "MARKET"
+
+	// GoodTilCancelled orders rest until they fill or are canceled
+	GoodTilCancelled = "GOOD_TIL_CANCELLED"
+	// ImmediateOrCancel orders take what they can and cancel the rest
+	ImmediateOrCancel = "IMMEDIATE_OR_CANCEL"
+	// FillOrKill orders fill completely right away or not at all
+	FillOrKill = "FILL_OR_KILL"
+	// PostOnly orders only ever rest, and are refused if they would take
+	PostOnly = "POST_ONLY_GOOD_TIL_CANCELLED"
+
+	// Open orders can still fill
+	Open = "OPEN"
+	// Closed orders are done filling, however much they got
+	Closed = "CLOSED"
+)
+
+// Exchange is a venue the bot can trade on
+type Exchange interface {
+	// Name says which venue this is, for logs
+	Name() string
+	// Ping checks the venue is up
+	Ping(ctx context.Context) error
+	// Account is who we are trading as
+	Account(ctx context.Context) (Account, error)
+	// Balances is everything the account holds
+	Balances(ctx context.Context) ([]Balance, error)
+	// Markets is every market the venue lists
+	Markets(ctx context.Context) ([]MarketInfo, error)
+	// Market is one market's trading rules
+	Market(ctx context.Context, symbol string) (MarketInfo, error)
+	// Ticker is a market's latest prices
+	Ticker(ctx context.Context, symbol string) (Ticker, error)
+	// Candles are a market's recent closed candles, oldest first
+	Candles(ctx context.Context, symbol string, interval string) ([]Candle, error)
+	// PlaceOrder sends an order. An order cut off after it may have reached the venue fails with an
+	// *UncertainOrderError, so it can be looked for later with FindOrder.
+	PlaceOrder(ctx context.Context, order OrderRequest) (Order, error)
+	// GetOrder looks an order up by the id the venue gave it
+	GetOrder(ctx context.Context, symbol string, id string) (Order, error)
+	// FindOrder looks an order up by the id we gave it, failing with ErrNotFound if the venue never saw it
+	FindOrder(ctx context.Context, symbol string, clientOrderID string) (Order, error)
+	// CancelOrder stops an order from filling any more
+	CancelOrder(ctx context.Context, symbol string, id string) (Order, error)
+	// OpenOrders is every order on a market that can still fill
+	OpenOrders(ctx context.Context, symbol string) ([]Order, error)
+}
+
+// Account is who we are trading as
+type Account struct {
+	ID string
+}
+
+// Balance is how much of one currency the account has, and how much of that isn't held by open orders
+type Balance struct {
+	Currency  string
+	Total     decimal.Decimal
+	Available decimal.Decimal
+}
+
+// MarketInfo is a market's trading rules. Quantities are multiples of StepSize and limits are multiples
+// of TickSize. Native is what the venue itself calls the market, like DOGEUSDT.
+type MarketInfo struct {
+	Symbol      string
+	Native      string
+	Base        string
+	Quote       string
+	MinQuantity decimal.Decimal
+	StepSize    decimal.Decimal
+	TickSize    decimal.Decimal
+	Active      bool
+}
+
+// Ticker is a market's latest prices
+type Ticker struct {
+	Symbol string
+	Last   decimal.Decimal
+	Bid    decimal.Decimal
+	Ask    decimal.Decimal
+}
+
+// Candle is a market's trading over one interval
+type Candle struct {
+	StartsAt time.Time
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	Volume   decimal.Decimal
+}
+
+// OrderRequest is an order we want placed. ClientOrderID is what lets us find it again if we lose track of it.
+type OrderRequest struct {
+	Symbol        string
+	Side          string
+	Type          string
+	Quantity      decimal.Decimal
+	Limit         decimal.Decimal
+	TimeInForce   string
+	ClientOrderID string
+}
+
+// Order is an order the venue has. Proceeds is the quote currency value of what filled.
+type Order struct {
+	ID             string
+	ClientOrderID  string
+	Symbol         string
+	Side           string
+	Type           string
+	TimeInForce    string
+	Quantity       decimal.Decimal
+	Limit          decimal.Decimal
+	FilledQuantity decimal.Decimal
+	Proceeds       decimal.Decimal
+	Commission     decimal.Decimal
+	Status         string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ClosedAt       time.Time
+}
@@ -0,0 +1,61 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ValidateOrder rounds an order to what the market accepts, or says why the market would turn it down.
+// Limits are rounded in the order's favor: buys down and sells up. Quantities are always rounded down.
+func ValidateOrder(order OrderRequest, market MarketInfo) (OrderRequest, error) {
+	if order.Symbol != market.Symbol {
+		return order, fmt.Errorf("%w: order is for %s but the market is %s", ErrInvalidOrder, order.Symbol, market.Symbol)
+	}
+	if !market.Active {
+		return order, fmt.Errorf("%w: %s isn't trading", ErrMarketOffline, market.Symbol)
+	}
+	if order.Side != Buy && order.Side != Sell {
+		return order, fmt.Errorf("%w: unknown side %q", ErrInvalidOrder, order.Side)
+	}
+
+	quantity := roundDown(order.Quantity, market.StepSize)
+	if !quantity.IsPositive() {
+		return order, fmt.Errorf("%w: quantity %v rounds to nothing", ErrInvalidOrder, order.Quantity)
+	}
+	if quantity.LessThan(market.MinQuantity) {
+		return order, fmt.Errorf("%w: quantity %s is below the minimum of %s %s", ErrMinTradeRequirementNotMet, quantity, market.MinQuantity, market.Base)
+	}
+	order.Quantity = quantity
+
+	if order.Type == Limit || !order.Limit.IsZero() {
+		limit := order.Limit
+		if order.Side == Buy {
+			limit = roundDown(limit, market.TickSize)
+		} else {
+			limit = roundUp(limit, market.TickSize)
+		}
+		if !limit.IsPositive() {
+			return order, fmt.Errorf("%w: limit %v rounds to nothing at a tick size of %s", ErrInvalidOrder, order.Limit, market.TickSize)
+		}
+		order.Limit = limit
+	}
+
+	return order, nil
+}
+
+// roundDown is d rounded down to a multiple of step. A zero step leaves d alone.
+func roundDown(d decimal.Decimal, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return d
+	}
+	return d.Div(step).Floor().Mul(step)
+}
+
+// roundUp is d rounded up to a multiple of step. A zero step leaves d alone.
+func roundUp(d decimal.Decimal, step decimal.Decimal) decimal.Decimal {
+	if !step.IsPositive() {
+		return d
+	}
+	return d.Div(step).Ceil().Mul(step)
+}
@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+var dogeMarket = MarketInfo{
+	Symbol:      "DOGE-USD",
+	Base:        "DOGE",
+	Quote:       "USD",
+	MinQuantity: decimal.NewFromInt(50),
+	StepSize:    decimal.RequireFromString("0.1"),
+	TickSize:    decimal.RequireFromString("0.00001"),
+	Active:      true,
+}
+
+func TestValidateOrderRounds(t *testing.T) {
+	buy, err := ValidateOrder(OrderRequest{Symbol: "DOGE-USD", Side: Buy, Type: Limit, Quantity: decimal.RequireFromString("123.456"), Limit: decimal.RequireFromString("0.0512349")}, dogeMarket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buy.Quantity.String() != "123.4" || buy.Limit.String() != "0.05123" {
+		t.Errorf("Buy rounded to %v at %v", buy.Quantity, buy.Limit)
+	}
+
+	sell, err := ValidateOrder(OrderRequest{Symbol: "DOGE-USD", Side: Sell, Type: Limit, Quantity: decimal.NewFromInt(100), Limit: decimal.RequireFromString("0.0512341")}, dogeMarket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sell.Limit.String() != "0.05124" {
+		t.Errorf("Sell limit rounded to %v, expected 0.05124", sell.Limit)
+	}
+}
+
+func TestValidateOrderRejects(t *testing.T) {
+	offline := dogeMarket
+	offline.Active = false
+
+	cases := []struct {
+		name   string
+		order  OrderRequest
+		market MarketInfo
+		want   error
+	}{
+		{"offline", OrderRequest{Symbol: "DOGE-USD", Side: Buy, Type: Market, Quantity: decimal.NewFromInt(100)}, offline, ErrMarketOffline},
+		{"wrong market", OrderRequest{Symbol: "BTC-USD", Side: Buy, Type: Market, Quantity: decimal.NewFromInt(100)}, dogeMarket, ErrInvalidOrder},
+		{"too small", OrderRequest{Symbol: "DOGE-USD", Side: Buy, Type: Market, Quantity: decimal.NewFromInt(49)}, dogeMarket, ErrMinTradeRequirementNotMet},
+		{"below a tick", OrderRequest{Symbol: "DOGE-USD", Side: Buy, Type: Limit, Quantity: decimal.NewFromInt(100), Limit: decimal.RequireFromString("0.000001")}, dogeMarket, ErrInvalidOrder},
+		{"no side", OrderRequest{Symbol: "DOGE-USD", Type: Market, Quantity: decimal.NewFromInt(100)}, dogeMarket, ErrInvalidOrder},
+	}
+	for _, c := range cases {
+		_, err := ValidateOrder(c.order, c.market)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+}
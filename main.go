@@ -1,13 +1,144 @@
 package main
 
 import (
+	"context"
+	"cryptofu/binance"
 	"cryptofu/bittrex"
 	"cryptofu/bot"
+	"cryptofu/coinbase"
+	"cryptofu/exchange"
+	"cryptofu/symbols"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
-	go bot.NewBot(bot.Modes["Paper"], bittrex.Symbols["Doge"])
-	<-bot.SelfDestruct
+	os.Exit(run())
+}
+
+// run is everything main does. It returns the exit code instead of exiting, so its deferred cleanup always runs.
+func run() int {
+	err := godotenv.Load()
+	if err != nil {
+		log.Print("💩 Error loading .env file")
+		return 1
+	}
+	options := []bittrex.Option{bittrex.WithCredentials(os.Getenv("BIT_KEY"), os.Getenv("BIT_SECRET"))}
+	// Set RECORD_CASSETTE to a file name to keep the session's traffic for replaying in tests
+	if name := os.Getenv("RECORD_CASSETTE"); name != "" {
+		recorder := bittrex.NewRecorder(nil)
+		options = append(options, bittrex.WithHTTPClient(&http.Client{Timeout: time.Second * 30, Transport: recorder}))
+		defer func() {
+			err := recorder.Save(name)
+			if err != nil {
+				fmt.Println("💩 Could not save the cassette:", err)
+			}
+		}()
+	}
+	var venue exchange.Exchange = bittrex.NewExchange(bittrex.NewClient(options...))
+	// Set EXCHANGE to binance or coinbase to trade there instead
+	switch os.Getenv("EXCHANGE") {
+	case "binance":
+		venue = binance.NewClient(binance.WithCredentials(os.Getenv("BINANCE_KEY"), os.Getenv("BINANCE_SECRET")))
+	case "coinbase":
+		var signer coinbase.Signer = coinbase.NewHMACSigner(os.Getenv("COINBASE_KEY"), os.Getenv("COINBASE_SECRET"))
+		// Cloud keys sign with a JWT instead
+		if keyName := os.Getenv("COINBASE_KEY_NAME"); keyName != "" {
+			signer, err = coinbase.NewJWTSigner(keyName, os.Getenv("COINBASE_PRIVATE_KEY"))
+			if err != nil {
+				log.Print("💩 ", err)
+				return 1
+			}
+		}
+		venue = coinbase.NewClient(coinbase.WithSigner(signer))
+	}
+	// Bots are set up by canonical pair, PAIR=ETH/USD, and the registry says what the venue trades it as.
+	// Every bot shares the one venue client, and with it the venue's rate limit.
+	pair := os.Getenv("PAIR")
+	if pair == "" {
+		pair = "DOGE/USD"
+	}
+	// Set BOT_CONFIG to a json file to run several bots, each with its own pair, interval, mode, strategy and tuning
+	config, err := loadConfig(os.Getenv("BOT_CONFIG"), pair)
+	if err != nil {
+		log.Print("💩 ", err)
+		return 1
+	}
+	// Backtests don't need the venue, so only go to it when a bot trades there
+	var registry *symbols.Registry
+	if config.needsVenue() {
+		cacheDir := os.Getenv("SYMBOL_CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = filepath.Join("data", "symbols")
+		}
+		registry, err = symbols.Load(context.Background(), venue, cacheDir, symbols.DefaultMaxAge)
+		if err != nil {
+			log.Print("💩 ", err)
+			return 1
+		}
+	}
+	orchestra := newOrchestrator()
+	for _, settings := range config.Bots {
+		symbol, err := settings.symbol(registry)
+		if err != nil {
+			log.Print("💩 ", err)
+			return 1
+		}
+		name := settings.Name
+		if name == "" {
+			name = symbol
+		}
+		mode := settings.Mode
+		strategy := settings.strategy()
+		options := []bot.Option{bot.WithName(name), bot.WithInterval(settings.Interval), bot.WithPeriods(settings.Periods)}
+		err = orchestra.add(name, func(ctx context.Context) (runner, error) {
+			return bot.NewBot(ctx, mode, symbol, venue, strategy, options...)
+		})
+		if err != nil {
+			log.Print("💩 ", err)
+			return 1
+		}
+	}
+	// The first SIGINT or SIGTERM lets the bots finish their rotations and save their state, a second one doesn't wait
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		sig := <-signals
+		fmt.Printf("🛑 Got %s, stopping after this rotation\n", sig)
+		stop()
+		<-signals
+		fmt.Println("💀 Stopping now")
+		os.Exit(1)
+	}()
+
+	// Set CONTROL_ADDR, like localhost:8089, to start, stop and restart bots over http while they run
+	if addr := os.Getenv("CONTROL_ADDR"); addr != "" {
+		server := &http.Server{Addr: addr, Handler: controlHandler(orchestra)}
+		go func() {
+			err := server.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				fmt.Println("💩 Control server stopped:", err)
+			}
+		}()
+		defer server.Close()
+	}
+
+	err = orchestra.run(ctx)
+	if err != nil {
+		fmt.Println("💩 Cryptofu gave up:", err)
+		return 1
+	}
 	fmt.Println("😲 Cryptofu shutting down! 🧨 💥")
+	return 0
 }
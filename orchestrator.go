@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// botRunning bots are trading, or backing off before a restart
+	botRunning = "running"
+	// botStopped bots were stopped by hand or by shutting down
+	botStopped = "stopped"
+	// botFinished bots stopped on their own without an error, like a backtest that ran out of history
+	botFinished = "finished"
+	// botFailed bots were given up on by their supervisor
+	botFailed = "failed"
+)
+
+var (
+	errUnknownBot          = errors.New("no bot by that name")
+	errDuplicateBot        = errors.New("a bot by that name already exists")
+	errBotRunning          = errors.New("bot is already running")
+	errBotNotRunning       = errors.New("bot isn't running")
+	errOrchestratorIdle    = errors.New("orchestrator isn't running")
+	errOrchestratorRunning = errors.New("orchestrator is already running")
+)
+
+// botStatus is what an orchestrated bot is up to. Err is why a failed bot was given up on.
+type botStatus struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Err   string `json:"error,omitempty"`
+}
+
+// managedBot is a bot the orchestrator looks after
+type managedBot struct {
+	name   string
+	start  func(ctx context.Context) (runner, error)
+	state  string
+	err    error
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// orchestrator runs several bots side by side, each under its own supervisor and with its own context, so a
+// bot failing or being stopped leaves the others trading
+type orchestrator struct {
+	mu   sync.Mutex
+	ctx  context.Context
+	bots map[string]*managedBot
+	// names keeps the order bots were added in
+	names []string
+	// exited is poked whenever a bot stops running
+	exited chan struct{}
+	// newSupervisor makes the supervisor a bot runs under
+	newSupervisor func(start func(ctx context.Context) (runner, error)) *supervisor
+}
+
+func newOrchestrator() *orchestrator {
+	return &orchestrator{
+		bots:          make(map[string]*managedBot),
+		names:         make([]string, 0),
+		exited:        make(chan struct{}, 1),
+		newSupervisor: newSupervisor,
+	}
+}
+
+// add hands the orchestrator a bot to run. start sets up a new instance of the bot whenever it is (re)started.
+func (o *orchestrator) add(name string, start func(ctx context.Context) (runner, error)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.bots[name]; ok {
+		return fmt.Errorf("%w: %s", errDuplicateBot, name)
+	}
+	o.bots[name] = &managedBot{name: name, start: start, state: botStopped}
+	o.names = append(o.names, name)
+	if o.ctx != nil {
+		o.launch(o.bots[name])
+	}
+	return nil
+}
+
+// run starts every bot and waits until ctx is done or every bot has finished or failed on its own, then waits
+// for them all to stop. Bots stopped by hand can be started again, so they keep run going. It returns an error
+// naming the bots that were given up on.
+func (o *orchestrator) run(ctx context.Context) error {
+	o.mu.Lock()
+	if o.ctx != nil {
+		o.mu.Unlock()
+		return errOrchestratorRunning
+	}
+	o.ctx = ctx
+	for _, name := range o.names {
+		o.launch(o.bots[name])
+	}
+	o.mu.Unlock()
+
+	for !o.settled() {
+		select {
+		case <-ctx.Done():
+			o.wait()
+			return o.failures()
+		case <-o.exited:
+		}
+	}
+	o.wait()
+	return o.failures()
+}
+
+// launch starts a bot's supervisor. o.mu has to be held.
+func (o *orchestrator) launch(bot *managedBot) {
+	ctx, cancel := context.WithCancel(o.ctx)
+	bot.state = botRunning
+	bot.err = nil
+	bot.cancel = cancel
+	bot.done = make(chan struct{})
+	s := o.newSupervisor(bot.start)
+	s.name = bot.name
+	go func(done chan struct{}) {
+		err := s.run(ctx)
+		o.mu.Lock()
+		switch {
+		case bot.state != botRunning:
+			// Stopped by hand
+		case err != nil:
+			bot.state = botFailed
+			bot.err = err
+			fmt.Printf("💩 %s gave up: %s\n", bot.name, err)
+		case ctx.Err() != nil:
+			bot.state = botStopped
+		default:
+			bot.state = botFinished
+		}
+		cancel()
+		close(done)
+		o.mu.Unlock()
+		select {
+		case o.exited <- struct{}{}:
+		default:
+		}
+	}(bot.done)
+}
+
+// start starts a bot that was stopped, finished or failed
+func (o *orchestrator) start(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.ctx == nil || o.ctx.Err() != nil {
+		return errOrchestratorIdle
+	}
+	bot, ok := o.bots[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownBot, name)
+	}
+	if bot.state == botRunning {
+		return fmt.Errorf("%w: %s", errBotRunning, name)
+	}
+	o.launch(bot)
+	return nil
+}
+
+// stop stops a bot and waits for it to finish its rotation and save its state
+func (o *orchestrator) stop(name string) error {
+	o.mu.Lock()
+	bot, ok := o.bots[name]
+	if !ok {
+		o.mu.Unlock()
+		return fmt.Errorf("%w: %s", errUnknownBot, name)
+	}
+	if bot.state != botRunning {
+		o.mu.Unlock()
+		return fmt.Errorf("%w: %s", errBotNotRunning, name)
+	}
+	bot.state = botStopped
+	bot.cancel()
+	done := bot.done
+	o.mu.Unlock()
+	<-done
+	return nil
+}
+
+// restart stops a running bot and starts a fresh instance of it. A bot that isn't running is just started.
+func (o *orchestrator) restart(name string) error {
+	err := o.stop(name)
+	if err != nil && !errors.Is(err, errBotNotRunning) {
+		return err
+	}
+	return o.start(name)
+}
+
+// statuses says what every bot is up to, in the order they were added
+func (o *orchestrator) statuses() []botStatus {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	statuses := make([]botStatus, 0, len(o.names))
+	for _, name := range o.names {
+		bot := o.bots[name]
+		status := botStatus{Name: name, State: bot.state}
+		if bot.err != nil {
+			status.Err = bot.err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// settled says whether every bot has finished or failed, so none will run again unless told to
+func (o *orchestrator) settled() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, bot := range o.bots {
+		if bot.state != botFinished && bot.state != botFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// wait blocks until every bot has stopped
+func (o *orchestrator) wait() {
+	o.mu.Lock()
+	dones := make([]chan struct{}, 0, len(o.bots))
+	for _, bot := range o.bots {
+		if bot.done != nil {
+			dones = append(dones, bot.done)
+		}
+	}
+	o.mu.Unlock()
+	for _, done := range dones {
+		<-done
+	}
+}
+
+// failures names the bots that were given up on, or is nil when there weren't any
+func (o *orchestrator) failures() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	failed := make([]string, 0)
+	for name, bot := range o.bots {
+		if bot.state == botFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, bot.err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("%d of %d bots gave up: %s", len(failed), len(o.bots), strings.Join(failed, "; "))
+}
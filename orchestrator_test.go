@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"cryptofu/exchange"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingRunner trades until it is stopped
+type blockingRunner struct{}
+
+func (blockingRunner) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// countingStart starts runner and counts how many times it was started
+type countingStart struct {
+	mu     sync.Mutex
+	starts int
+	runner runner
+}
+
+func (c *countingStart) start(ctx context.Context) (runner, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.starts++
+	return c.runner, nil
+}
+
+func (c *countingStart) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.starts
+}
+
+func testOrchestrator() *orchestrator {
+	o := newOrchestrator()
+	o.newSupervisor = func(start func(ctx context.Context) (runner, error)) *supervisor {
+		s := newSupervisor(start)
+		s.baseDelay = time.Millisecond
+		s.maxDelay = time.Millisecond
+		return s
+	}
+	return o
+}
+
+func runOrchestrator(o *orchestrator) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- o.run(ctx)
+	}()
+	return cancel, result
+}
+
+func stateOf(o *orchestrator, name string) string {
+	for _, status := range o.statuses() {
+		if status.Name == name {
+			return status.State
+		}
+	}
+	return ""
+}
+
+func waitForState(t *testing.T, o *orchestrator, name string, state string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for stateOf(o, name) != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is %s, expected %s", name, stateOf(o, name), state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestOrchestratorKeepsBotsApart(t *testing.T) {
+	o := testOrchestrator()
+	doge := &countingStart{runner: blockingRunner{}}
+	btc := &countingStart{runner: fakeRunner{err: exchange.ErrBadCredentials}}
+	o.add("doge", doge.start)
+	o.add("btc", btc.start)
+	err := o.add("doge", doge.start)
+	if !errors.Is(err, errDuplicateBot) {
+		t.Errorf("Got %v, expected a duplicate", err)
+	}
+
+	cancel, result := runOrchestrator(o)
+	// A bot giving up leaves the others trading
+	waitForState(t, o, "btc", botFailed)
+	if stateOf(o, "doge") != botRunning {
+		t.Errorf("doge is %s, expected it to keep running", stateOf(o, "doge"))
+	}
+
+	cancel()
+	err = <-result
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 bots gave up: btc") {
+		t.Errorf("Got %v, expected btc to have given up", err)
+	}
+	if stateOf(o, "doge") != botStopped {
+		t.Errorf("doge is %s, expected it stopped", stateOf(o, "doge"))
+	}
+}
+
+func TestOrchestratorControlsBotsOneAtATime(t *testing.T) {
+	o := testOrchestrator()
+	doge := &countingStart{runner: blockingRunner{}}
+	btc := &countingStart{runner: blockingRunner{}}
+	o.add("doge", doge.start)
+	o.add("btc", btc.start)
+	err := o.start("doge")
+	if !errors.Is(err, errOrchestratorIdle) {
+		t.Errorf("Got %v, expected the orchestrator to be idle", err)
+	}
+
+	cancel, result := runOrchestrator(o)
+	defer cancel()
+	waitForState(t, o, "doge", botRunning)
+	waitForState(t, o, "btc", botRunning)
+
+	err = o.stop("doge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stateOf(o, "doge") != botStopped || stateOf(o, "btc") != botRunning {
+		t.Errorf("Got %+v, expected only doge stopped", o.statuses())
+	}
+	err = o.stop("doge")
+	if !errors.Is(err, errBotNotRunning) {
+		t.Errorf("Got %v, expected doge not to be running", err)
+	}
+	err = o.start("doge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.start("doge")
+	if !errors.Is(err, errBotRunning) {
+		t.Errorf("Got %v, expected doge to be running", err)
+	}
+	err = o.restart("btc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, o, "btc", botRunning)
+	deadline := time.Now().Add(time.Second * 5)
+	for doge.count() != 2 || btc.count() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Started doge %d and btc %d times, expected twice each", doge.count(), btc.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	err = o.stop("eth")
+	if !errors.Is(err, errUnknownBot) {
+		t.Errorf("Got %v, expected eth to be unknown", err)
+	}
+
+	cancel()
+	err = <-result
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrchestratorKeepsGoingWhenTheOnlyBotIsStopped(t *testing.T) {
+	o := testOrchestrator()
+	doge := &countingStart{runner: blockingRunner{}}
+	o.add("doge", doge.start)
+	cancel, result := runOrchestrator(o)
+	defer cancel()
+	waitForState(t, o, "doge", botRunning)
+
+	err := o.stop("doge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-result:
+		t.Fatalf("The orchestrator stopped with %v when its only bot was stopped by hand", err)
+	case <-time.After(time.Millisecond * 50):
+	}
+	err = o.start("doge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, o, "doge", botRunning)
+
+	cancel()
+	err = <-result
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrchestratorReturnsWhenEveryBotFinishes(t *testing.T) {
+	o := testOrchestrator()
+	o.add("backtest-doge", (&countingStart{runner: fakeRunner{}}).start)
+	o.add("backtest-btc", (&countingStart{runner: fakeRunner{}}).start)
+
+	_, result := runOrchestrator(o)
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("The orchestrator kept running without any bots")
+	}
+	if stateOf(o, "backtest-doge") != botFinished || stateOf(o, "backtest-btc") != botFinished {
+		t.Errorf("Got %+v, expected both finished", o.statuses())
+	}
+}
+
+func TestControlHandler(t *testing.T) {
+	o := testOrchestrator()
+	o.add("doge", (&countingStart{runner: blockingRunner{}}).start)
+	cancel, result := runOrchestrator(o)
+	defer func() {
+		cancel()
+		<-result
+	}()
+	waitForState(t, o, "doge", botRunning)
+	server := httptest.NewServer(controlHandler(o))
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/bots/doge/stop", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []botStatus
+	err = json.NewDecoder(res.Body).Decode(&statuses)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || len(statuses) != 1 || statuses[0].State != botStopped {
+		t.Errorf("Got %d %+v, expected doge stopped", res.StatusCode, statuses)
+	}
+
+	for _, c := range []struct {
+		path     string
+		expected int
+	}{
+		{"/bots/doge/stop", http.StatusConflict},
+		{"/bots/eth/start", http.StatusNotFound},
+		{"/bots/doge/sell", http.StatusNotFound},
+		{"/bots/doge/start", http.StatusOK},
+	} {
+		res, err := http.Post(server.URL+c.path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != c.expected {
+			t.Errorf("%s got %d, expected %d", c.path, res.StatusCode, c.expected)
+		}
+	}
+
+	res, err = http.Get(server.URL + "/bots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Got %d listing bots", res.StatusCode)
+	}
+}
@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllowsABurst(t *testing.T) {
+	limiter := New(0.1, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Call %d was %v, expected the burst to allow it", i+1, err)
+		}
+	}
+}
+
+func TestLimiterRespectsContext(t *testing.T) {
+	limiter := New(0.1, 1)
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Was %v, expected the deadline", err)
+	}
+}
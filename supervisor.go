@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"cryptofu/exchange"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// runner is a bot that has been set up and is ready to trade
+type runner interface {
+	Run(ctx context.Context) error
+}
+
+// supervisor keeps a bot going. A bot that fails to set up or stops with an error is started again after a
+// backoff, unless the error is one a restart won't fix or it keeps failing.
+type supervisor struct {
+	// name is which bot this is, for logs
+	name string
+	// start sets up a new bot
+	start func(ctx context.Context) (runner, error)
+	// maxFailures is how many failures in a row are given up on
+	maxFailures int
+	// baseDelay is the backoff after the first failure, it doubles every failure after that
+	baseDelay time.Duration
+	// maxDelay caps the backoff
+	maxDelay time.Duration
+	// healthyAfter is how long a bot has to run before its failures stop counting against it
+	healthyAfter time.Duration
+	now          func() time.Time
+}
+
+func newSupervisor(start func(ctx context.Context) (runner, error)) *supervisor {
+	return &supervisor{
+		name:         "Bot",
+		start:        start,
+		maxFailures:  5,
+		baseDelay:    time.Second * 5,
+		maxDelay:     time.Minute * 5,
+		healthyAfter: time.Minute * 10,
+		now:          time.Now,
+	}
+}
+
+// permanent says whether an error would happen again on restart
+func permanent(err error) bool {
+	return errors.Is(err, exchange.ErrBadCredentials)
+}
+
+// run keeps the bot going until ctx is done or the bot finishes, which return nil, or until it gives up,
+// which returns the error it gave up on
+func (s *supervisor) run(ctx context.Context) error {
+	failures := 0
+	for {
+		started := s.now()
+		bot, err := s.start(ctx)
+		if err == nil {
+			err = bot.Run(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if permanent(err) {
+			return err
+		}
+		if s.now().Sub(started) >= s.healthyAfter {
+			failures = 0
+		}
+		failures++
+		if failures >= s.maxFailures {
+			return fmt.Errorf("gave up after %d failures in a row: %w", failures, err)
+		}
+
+		delay := s.baseDelay << uint(failures-1)
+		if delay <= 0 || delay > s.maxDelay {
+			delay = s.maxDelay
+		}
+		fmt.Printf("🤕 %s stopped with %s, restarting in %s\n", s.name, err, delay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
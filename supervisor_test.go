@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"cryptofu/exchange"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeRunner stops with its error straight away
+type fakeRunner struct {
+	err error
+}
+
+func (r fakeRunner) Run(ctx context.Context) error {
+	return r.err
+}
+
+func testSupervisor(results ...error) (*supervisor, *int) {
+	starts := 0
+	s := newSupervisor(func(ctx context.Context) (runner, error) {
+		result := results[starts]
+		starts++
+		return fakeRunner{err: result}, nil
+	})
+	s.baseDelay = time.Millisecond
+	s.maxDelay = time.Millisecond * 4
+	return s, &starts
+}
+
+func TestSupervisorRestartsUntilDone(t *testing.T) {
+	s, starts := testSupervisor(errors.New("boom"), errors.New("boom"), nil)
+	err := s.run(context.Background())
+	if err != nil || *starts != 3 {
+		t.Errorf("Got %v after %d starts, expected nil after 3", err, *starts)
+	}
+}
+
+func TestSupervisorGivesUp(t *testing.T) {
+	s, starts := testSupervisor(errors.New("boom"), errors.New("boom"), errors.New("boom"), errors.New("boom"), errors.New("boom"), nil)
+	err := s.run(context.Background())
+	if err == nil || *starts != 5 {
+		t.Errorf("Got %v after %d starts, expected to give up after 5", err, *starts)
+	}
+
+	badKey := fmt.Errorf("account: %w", exchange.ErrBadCredentials)
+	s, starts = testSupervisor(badKey, nil)
+	err = s.run(context.Background())
+	if !errors.Is(err, exchange.ErrBadCredentials) || *starts != 1 {
+		t.Errorf("Got %v after %d starts, expected bad credentials to end it at once", err, *starts)
+	}
+}
+
+func TestSupervisorRetriesSetup(t *testing.T) {
+	starts := 0
+	s := newSupervisor(func(ctx context.Context) (runner, error) {
+		starts++
+		if starts < 3 {
+			return nil, errors.New("exchange is down")
+		}
+		return fakeRunner{}, nil
+	})
+	s.baseDelay = time.Millisecond
+	err := s.run(context.Background())
+	if err != nil || starts != 3 {
+		t.Errorf("Got %v after %d starts, expected nil after 3", err, starts)
+	}
+}
+
+func TestSupervisorStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newSupervisor(func(ctx context.Context) (runner, error) {
+		cancel()
+		return fakeRunner{err: errors.New("cut off")}, nil
+	})
+	err := s.run(ctx)
+	if err != nil {
+		t.Errorf("Expected stopping to be a clean exit, got %v", err)
+	}
+}
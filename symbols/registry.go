@@ -0,0 +1,151 @@
+// Package symbols keeps each venue's markets by canonical pair, so bots can be set up with ETH/USD and trade
+// whatever the venue calls it.
+//
+// A registry is loaded from the venue's markets and cached as json at <dir>/<venue>.json, so starting a bot
+// doesn't always cost a markets call, and still works while the markets endpoint is down.
+package symbols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cryptofu/exchange"
+)
+
+const (
+	// DefaultMaxAge is how old a cached registry can be before it is loaded again
+	DefaultMaxAge = time.Hour * 24
+)
+
+// Registry is one venue's markets by canonical pair
+type Registry struct {
+	Venue     string
+	FetchedAt time.Time
+	markets   map[string]exchange.MarketInfo
+}
+
+// cacheFile is how a registry is kept on disk
+type cacheFile struct {
+	Venue     string
+	FetchedAt time.Time
+	Markets   []exchange.MarketInfo
+}
+
+// New makes a registry out of a venue's markets
+func New(venue string, fetchedAt time.Time, markets []exchange.MarketInfo) *Registry {
+	r := &Registry{Venue: venue, FetchedAt: fetchedAt, markets: map[string]exchange.MarketInfo{}}
+	for _, market := range markets {
+		r.markets[Pair(market.Base, market.Quote)] = market
+	}
+	return r
+}
+
+// Load is the venue's registry from the cache in dir if it is younger than maxAge, otherwise it asks the
+// venue and caches the answer. A stale cache is still used if the venue can't be reached.
+func Load(ctx context.Context, venue exchange.Exchange, dir string, maxAge time.Duration) (*Registry, error) {
+	path := filepath.Join(dir, venue.Name()+".json")
+	cached, cacheErr := readCache(path)
+	if cacheErr == nil && time.Since(cached.FetchedAt) < maxAge {
+		return cached, nil
+	}
+
+	markets, err := venue.Markets(ctx)
+	if err != nil {
+		if cacheErr == nil {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("loading %s markets: %w", venue.Name(), err)
+	}
+	r := New(venue.Name(), time.Now().UTC(), markets)
+	return r, r.Save(path)
+}
+
+func readCache(path string) (*Registry, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cache cacheFile
+	err = json.Unmarshal(content, &cache)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return New(cache.Venue, cache.FetchedAt, cache.Markets), nil
+}
+
+// Save writes the registry to path. It writes a temporary file and renames it, so a crash never leaves half a cache.
+func (r *Registry) Save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(cacheFile{Venue: r.Venue, FetchedAt: r.FetchedAt, Markets: r.Markets()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, content, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Pair is the canonical name for a market, like ETH/USD
+func Pair(base string, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+// ParsePair splits a canonical pair into its base and quote. ETH-USD is taken as well as ETH/USD.
+func ParsePair(pair string) (string, string, error) {
+	parts := strings.FieldsFunc(pair, func(r rune) bool { return r == '/' || r == '-' })
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("%w: %q isn't a BASE/QUOTE pair", exchange.ErrNotFound, pair)
+	}
+	return strings.ToUpper(parts[0]), strings.ToUpper(parts[1]), nil
+}
+
+// Lookup is a pair's market on the venue
+func (r *Registry) Lookup(pair string) (exchange.MarketInfo, error) {
+	base, quote, err := ParsePair(pair)
+	if err != nil {
+		return exchange.MarketInfo{}, err
+	}
+	market, ok := r.markets[Pair(base, quote)]
+	if !ok {
+		return exchange.MarketInfo{}, fmt.Errorf("%w: %s has no %s market", exchange.ErrNotFound, r.Venue, Pair(base, quote))
+	}
+	return market, nil
+}
+
+// Symbol is the symbol to trade a pair by through the exchange.Exchange interface. It fails for markets that
+// aren't trading.
+func (r *Registry) Symbol(pair string) (string, error) {
+	market, err := r.Lookup(pair)
+	if err != nil {
+		return "", err
+	}
+	if !market.Active {
+		return "", fmt.Errorf("%w: %s isn't trading on %s", exchange.ErrMarketOffline, Pair(market.Base, market.Quote), r.Venue)
+	}
+	return market.Symbol, nil
+}
+
+// Markets is every market the venue lists, by pair
+func (r *Registry) Markets() []exchange.MarketInfo {
+	ret := make([]exchange.MarketInfo, 0, len(r.markets))
+	for _, market := range r.markets {
+		ret = append(ret, market)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return Pair(ret[i].Base, ret[i].Quote) < Pair(ret[j].Base, ret[j].Quote)
+	})
+	return ret
+}
@@ -0,0 +1,119 @@
+package symbols
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cryptofu/exchange"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeVenue only knows its markets, and counts how often it is asked for them
+type fakeVenue struct {
+	exchange.Exchange
+	markets []exchange.MarketInfo
+	err     error
+	calls   int
+}
+
+func (v *fakeVenue) Name() string {
+	return "fake"
+}
+
+func (v *fakeVenue) Markets(ctx context.Context) ([]exchange.MarketInfo, error) {
+	v.calls++
+	return v.markets, v.err
+}
+
+func testMarkets() []exchange.MarketInfo {
+	return []exchange.MarketInfo{
+		{Symbol: "ETH-USD", Native: "ETHUSDT", Base: "ETH", Quote: "USD", MinQuantity: decimal.RequireFromString("0.0001"), StepSize: decimal.RequireFromString("0.0001"), TickSize: decimal.RequireFromString("0.01"), Active: true},
+		{Symbol: "DOGE-USD", Native: "DOGEUSDT", Base: "DOGE", Quote: "USD", MinQuantity: decimal.NewFromInt(1), StepSize: decimal.NewFromInt(1), TickSize: decimal.RequireFromString("0.00001"), Active: true},
+		{Symbol: "LUNA-USD", Native: "LUNAUSDT", Base: "LUNA", Quote: "USD", Active: false},
+	}
+}
+
+func TestLookupByPair(t *testing.T) {
+	r := New("fake", time.Now(), testMarkets())
+
+	for _, pair := range []string{"ETH/USD", "eth/usd", "ETH-USD"} {
+		market, err := r.Lookup(pair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if market.Native != "ETHUSDT" || market.TickSize.String() != "0.01" || market.StepSize.String() != "0.0001" {
+			t.Errorf("%s was %+v", pair, market)
+		}
+	}
+	symbol, err := r.Symbol("DOGE/USD")
+	if err != nil || symbol != "DOGE-USD" {
+		t.Errorf("DOGE/USD trades as %s, %v", symbol, err)
+	}
+
+	_, err = r.Symbol("LUNA/USD")
+	if !errors.Is(err, exchange.ErrMarketOffline) {
+		t.Errorf("Expected an inactive market to be offline, got %v", err)
+	}
+	_, err = r.Lookup("SHIB/USD")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected an unknown pair to be not found, got %v", err)
+	}
+	_, err = r.Lookup("ETHUSD")
+	if !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Expected a pair without a quote to be not found, got %v", err)
+	}
+
+	markets := r.Markets()
+	if len(markets) != 3 || markets[0].Base != "DOGE" || markets[2].Base != "LUNA" {
+		t.Errorf("Markets were %+v", markets)
+	}
+}
+
+func TestLoadCaches(t *testing.T) {
+	dir := t.TempDir()
+	venue := &fakeVenue{markets: testMarkets()}
+	ctx := context.Background()
+
+	r, err := Load(ctx, venue, dir, DefaultMaxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if venue.calls != 1 || len(r.Markets()) != 3 {
+		t.Fatalf("Loaded %d markets in %d calls", len(r.Markets()), venue.calls)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fake.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = Load(ctx, venue, dir, DefaultMaxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if venue.calls != 1 {
+		t.Errorf("Expected a fresh cache to be used, the venue was asked %d times", venue.calls)
+	}
+	market, err := r.Lookup("ETH/USD")
+	if err != nil || !market.MinQuantity.Equal(decimal.RequireFromString("0.0001")) || !market.Active {
+		t.Errorf("Cached ETH/USD was %+v, %v", market, err)
+	}
+
+	// A stale cache is refreshed, and still used when the venue is down
+	venue.err = errors.New("down")
+	r, err = Load(ctx, venue, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if venue.calls != 2 || len(r.Markets()) != 3 {
+		t.Errorf("Expected the stale cache after %d calls, got %d markets", venue.calls, len(r.Markets()))
+	}
+
+	_, err = Load(ctx, venue, t.TempDir(), DefaultMaxAge)
+	if err == nil {
+		t.Error("Expected loading with no cache and no venue to fail")
+	}
+}